@@ -56,10 +56,29 @@ func main() {
 				Usage:   "Authentication token for ClippingKK service",
 				Value:   "",
 			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Aliases: []string{"p"},
+				Usage:   "Config profile to use (default: default_profile from the config file)",
+				Value:   "",
+			},
 		},
 		Commands: []*cli.Command{
 			commands.LoginCommand,
+			commands.WhoamiCommand,
+			commands.LogoutCommand,
 			commands.ParseCommand,
+			commands.PullCommand,
+			commands.SyncCommand,
+			commands.ClippingsCommand,
+			commands.DevicesCommand,
+			commands.WatchCommand,
+			commands.ServeCommand,
+			commands.SearchCommand,
+			commands.StatsCommand,
+			commands.BooksCommand,
+			commands.ProfileCommand,
+			commands.ConfigCommand,
 		},
 		Before: func(c *cli.Context) error {
 			// Inject global configuration context
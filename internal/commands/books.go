@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/clippingkk/cli/internal/library"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/urfave/cli/v2"
+)
+
+// bookSorts orders books for the --sort flag of "ck-cli books"
+var bookSorts = map[string]func(a, b library.Book) bool{
+	"title":     func(a, b library.Book) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) },
+	"author":    func(a, b library.Book) bool { return strings.ToLower(a.Author) < strings.ToLower(b.Author) },
+	"clippings": func(a, b library.Book) bool { return a.Clippings > b.Clippings },
+	"first": func(a, b library.Book) bool {
+		// Undated books go last
+		if a.FirstAddedAt.IsZero() != b.FirstAddedAt.IsZero() {
+			return !a.FirstAddedAt.IsZero()
+		}
+		return a.FirstAddedAt.Before(b.FirstAddedAt)
+	},
+	"last": func(a, b library.Book) bool { return a.LastAddedAt.After(b.LastAddedAt) },
+}
+
+// BooksCommand lists the books of a clippings source
+var BooksCommand = &cli.Command{
+	Name:  "books",
+	Usage: "List the books of a clippings file with counts, locations and dates",
+	Description: `List the distinct books of an input file or the local library configured
+as [library] input, with their author, clipping counts by kind, the range of
+locations covered and when they were highlighted.
+
+Examples:
+  # Books most recently read first
+  ck-cli books -i "My Clippings.txt"
+
+  # Most highlighted books first, as JSON
+  ck-cli books --sort clippings --json
+
+  # Every clipping of a book in reading order
+  ck-cli books show -i "My Clippings.txt" "Bad Blood"`,
+	Flags: []cli.Flag{
+		libraryInputFlag(),
+		&cli.StringFlag{
+			Name:  "sort",
+			Usage: "Sort by 'title', 'author', 'clippings', 'first' or 'last' (most recent first)",
+			Value: "last",
+		},
+		&cli.BoolFlag{
+			Name:  "reverse",
+			Usage: "Reverse the sort order",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print books as JSON",
+		},
+	},
+	Action: booksAction,
+	Subcommands: []*cli.Command{
+		{
+			Name:      "show",
+			Usage:     "Print the clippings of one book in reading order",
+			ArgsUsage: "<title>",
+			Flags: []cli.Flag{
+				libraryInputFlag(),
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print clippings as JSON",
+				},
+			},
+			Action: booksShowAction,
+		},
+	},
+}
+
+func booksAction(c *cli.Context) error {
+	less, ok := bookSorts[c.String("sort")]
+	if !ok {
+		return fmt.Errorf("invalid --sort %q: must be 'title', 'author', 'clippings', 'first' or 'last'", c.String("sort"))
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	clippings, err := loadLibrary(GetContext(), c, cfg)
+	if err != nil {
+		return err
+	}
+
+	books := library.Books(clippings)
+	sort.SliceStable(books, func(i, j int) bool {
+		if c.Bool("reverse") {
+			return less(books[j], books[i])
+		}
+		return less(books[i], books[j])
+	})
+
+	if c.Bool("json") {
+		return outputJSON(os.Stdout, books)
+	}
+	return printBooks(os.Stdout, books)
+}
+
+// printBooks prints one table row per book
+func printBooks(w io.Writer, books []library.Book) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TITLE\tAUTHOR\tHIGHLIGHTS\tNOTES\tBOOKMARKS\tLOCATIONS\tFIRST\tLAST")
+	for _, book := range books {
+		locations := "-"
+		if book.LocationEnd > 0 {
+			locations = fmt.Sprintf("%d-%d", book.LocationStart, book.LocationEnd)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\n", book.Title, book.Author,
+			book.Kinds[models.KindHighlight], book.Kinds[models.KindNote], book.Kinds[models.KindBookmark],
+			locations, formatDay(book.FirstAddedAt), formatDay(book.LastAddedAt))
+	}
+	return tw.Flush()
+}
+
+func booksShowAction(c *cli.Context) error {
+	query := strings.TrimSpace(strings.Join(c.Args().Slice(), " "))
+	if query == "" {
+		return fmt.Errorf("expected a book title")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	clippings, err := loadLibrary(GetContext(), c, cfg)
+	if err != nil {
+		return err
+	}
+
+	title, err := findBook(library.Books(clippings), query)
+	if err != nil {
+		return err
+	}
+	selected := library.ReadingOrder(library.Filter{Book: title}.Select(clippings))
+
+	if c.Bool("json") {
+		return outputJSON(os.Stdout, selected)
+	}
+
+	book := library.Books(selected)[0]
+	header := book.Title
+	if book.Author != "" {
+		header += " — " + book.Author
+	}
+	fmt.Fprintf(os.Stdout, "%s (%d clippings)\n", header, book.Clippings)
+	for _, item := range selected {
+		kind := ""
+		if item.Kind != "" && item.Kind != models.KindHighlight {
+			kind = "[" + item.Kind + "] "
+		}
+		fmt.Fprintf(os.Stdout, "\n%s  %s\n  %s%s\n", item.PageAt, formatDay(item.CreatedAt), kind, item.Content)
+	}
+	return nil
+}
+
+// findBook picks the title equal to query, ignoring case, else the only
+// title containing it
+func findBook(books []library.Book, query string) (string, error) {
+	var candidates []string
+	for _, book := range books {
+		if strings.EqualFold(strings.TrimSpace(book.Title), query) {
+			return book.Title, nil
+		}
+		if strings.Contains(strings.ToLower(book.Title), strings.ToLower(query)) {
+			candidates = append(candidates, book.Title)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("no book matching %q", query)
+	case 1:
+		return candidates[0], nil
+	default:
+		sort.Strings(candidates)
+		return "", fmt.Errorf("%q matches %d books, be more specific:\n  %s", query, len(candidates), strings.Join(candidates, "\n  "))
+	}
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/internal/library"
+)
+
+func TestFindBook(t *testing.T) {
+	books := []library.Book{{Title: "Dune"}, {Title: "Dune Messiah"}, {Title: "论法的精神"}}
+
+	tests := []struct {
+		query    string
+		expected string
+		wantErr  bool
+	}{
+		{"dune", "Dune", false},
+		{"messiah", "Dune Messiah", false},
+		{"法的", "论法的精神", false},
+		{"un", "", true},
+		{"Bad Blood", "", true},
+	}
+
+	for _, test := range tests {
+		title, err := findBook(books, test.query)
+		if (err != nil) != test.wantErr || title != test.expected {
+			t.Errorf("findBook(%q) = %q, %v, expected %q (error %v)", test.query, title, err, test.expected, test.wantErr)
+		}
+	}
+}
+
+func TestSortByFirstPutsUndatedLast(t *testing.T) {
+	books := []library.Book{
+		{Title: "Undated"},
+		{Title: "Later", FirstAddedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "Earlier", FirstAddedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	less := bookSorts["first"]
+	sort.SliceStable(books, func(i, j int) bool { return less(books[i], books[j]) })
+
+	if books[0].Title != "Earlier" || books[1].Title != "Later" || books[2].Title != "Undated" {
+		t.Errorf("unexpected order %v, %v, %v", books[0].Title, books[1].Title, books[2].Title)
+	}
+}
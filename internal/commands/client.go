@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/clippingkk/cli/pkg/ckk"
+)
+
+// newClient creates a ClippingKK client for the active profile of cfg,
+// authenticating with its scheme, connecting with the [http.tls] and
+// [http.proxy] settings and printing upload progress to stderr
+func newClient(cfg *config.Config, opts ...ckk.Option) (*ckk.Client, error) {
+	auth, err := cfg.Auth()
+	if err != nil {
+		return nil, err
+	}
+	transport, err := cfg.HTTP.TransportConfig.Options()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(append([]ckk.Option{
+		ckk.WithHeaders(cfg.HTTP.Headers),
+		ckk.WithProgress(ckk.NewWriterProgress(os.Stderr)),
+	}, transport...), opts...)
+	if auth != nil {
+		opts = append(opts, ckk.WithAuth(auth))
+	}
+	return ckk.NewClient(cfg.HTTP.Endpoint, opts...), nil
+}
+
+// newSyncClient creates a client uploading with the visibility settings and,
+// when resolveBooks is set, the book mappings of cfg
+func newSyncClient(cfg *config.Config, resolveBooks bool) (*ckk.Client, error) {
+	policy, err := cfg.Sync.Policy()
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync visibility settings: %w", err)
+	}
+	client, err := newClient(cfg, ckk.WithVisibility(policy))
+	if err != nil {
+		return nil, err
+	}
+	if !resolveBooks {
+		return client, nil
+	}
+
+	mappingPath, err := cfg.Books.ResolvedMappingFile()
+	if err != nil {
+		return nil, err
+	}
+	cachePath, err := cfg.Books.ResolvedCacheFile()
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := ckk.NewBookResolver(client, mappingPath, cachePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up book resolution: %w", err)
+	}
+	client.SetBookResolver(resolver)
+	return client, nil
+}
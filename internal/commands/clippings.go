@@ -0,0 +1,240 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/prompt"
+	"github.com/clippingkk/cli/pkg/ckk"
+	"github.com/urfave/cli/v2"
+)
+
+// ClippingsCommand manages clippings stored on the ClippingKK service
+var ClippingsCommand = &cli.Command{
+	Name:  "clippings",
+	Usage: "Edit or delete clippings on ClippingKK service",
+	Description: `Fix typos and remove bad imports from your ClippingKK account.
+
+Every change asks for confirmation; pass --yes to skip the prompt in scripts.
+Clipping IDs are shown by "ck-cli pull".
+
+Examples:
+  # Fix a typo
+  ck-cli clippings edit 1234 --content "Susan was mortified"
+
+  # Delete a single clipping
+  ck-cli clippings rm 1234
+
+  # Delete every clipping of a book imported by mistake
+  ck-cli clippings rm --book "Bad Blood"
+
+  # Delete everything created before a date, without prompting
+  ck-cli clippings rm --before 2015-01-01 --yes`,
+	Subcommands: []*cli.Command{
+		{
+			Name:      "edit",
+			Usage:     "Change the content or location of a clipping",
+			ArgsUsage: "<id>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "content",
+					Usage: "New clipping content",
+				},
+				&cli.StringFlag{
+					Name:  "page-at",
+					Usage: "New clipping location, e.g. #42-43",
+				},
+				yesFlag(),
+			},
+			Action: clippingsEditAction,
+		},
+		{
+			Name:      "rm",
+			Usage:     "Delete clippings by ID, book or creation date",
+			ArgsUsage: "[<id>...]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "book",
+					Usage: "Delete every clipping of this book title",
+				},
+				&cli.StringFlag{
+					Name:  "before",
+					Usage: "Delete clippings created before this date (YYYY-MM-DD or RFC3339)",
+				},
+				yesFlag(),
+			},
+			Action: clippingsRemoveAction,
+		},
+	},
+}
+
+// yesFlag returns the --yes flag skipping confirmation prompts
+func yesFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "Do not ask for confirmation",
+	}
+}
+
+// confirm asks for confirmation on stderr unless --yes was given
+func confirm(c *cli.Context, question string) (bool, error) {
+	if c.Bool("yes") {
+		return true, nil
+	}
+	return prompt.Confirm(os.Stdin, os.Stderr, question)
+}
+
+func clippingsEditAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one clipping ID")
+	}
+	id, err := parseClippingID(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	var update ckk.ClippingUpdate
+	if c.IsSet("content") {
+		content := c.String("content")
+		update.Content = &content
+	}
+	if c.IsSet("page-at") {
+		pageAt := c.String("page-at")
+		update.PageAt = &pageAt
+	}
+	if update.Content == nil && update.PageAt == nil {
+		return fmt.Errorf("nothing to change: pass --content and/or --page-at")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := requireToken(cfg); err != nil {
+		return err
+	}
+
+	ok, err := confirm(c, fmt.Sprintf("Update clipping %d?", id))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Aborted\n")
+		return nil
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	if err := client.UpdateClipping(GetContext(), id, update); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Updated clipping %d\n", id)
+	return nil
+}
+
+func clippingsRemoveAction(c *cli.Context) error {
+	ctx := GetContext()
+
+	book, before := c.String("book"), c.String("before")
+	if c.NArg() == 0 && book == "" && before == "" {
+		return fmt.Errorf("specify clipping IDs, --book or --before")
+	}
+	if c.NArg() > 0 && (book != "" || before != "") {
+		return fmt.Errorf("clipping IDs cannot be combined with --book or --before")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := requireToken(cfg); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+	for _, arg := range c.Args().Slice() {
+		id, err := parseClippingID(arg)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	// Select clippings by book and date from the server library
+	if c.NArg() == 0 {
+		opts := ckk.FetchOptions{OnSkip: warnSkipped}
+		if book != "" {
+			opts.Books = []string{book}
+		}
+		var cutoff time.Time
+		if before != "" {
+			cutoff, err = parseDate(before)
+			if err != nil {
+				return fmt.Errorf("invalid --before: %w", err)
+			}
+		}
+
+		clippings, err := client.FetchClippings(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, item := range clippings {
+			if !cutoff.IsZero() && !item.CreatedAt.Before(cutoff) {
+				continue
+			}
+			ids = append(ids, item.ID)
+		}
+		printSelection(clippings, ids)
+	}
+
+	if len(ids) == 0 {
+		fmt.Fprintf(os.Stderr, "No matching clippings\n")
+		return nil
+	}
+
+	ok, err := confirm(c, fmt.Sprintf("Delete %d clippings?", len(ids)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Aborted\n")
+		return nil
+	}
+
+	deleted, err := client.DeleteClippings(ctx, ids)
+	fmt.Fprintf(os.Stderr, "🗑️  Deleted %d of %d clippings\n", len(deleted), len(ids))
+	return err
+}
+
+// printSelection lists the selected clippings on stderr before confirming
+func printSelection(clippings []models.ClippingItem, ids []int64) {
+	selected := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	for _, item := range clippings {
+		if selected[item.ID] {
+			fmt.Fprintf(os.Stderr, "  %d  %s %s  %s\n", item.ID, item.Title, item.PageAt, preview(item.Content))
+		}
+	}
+}
+
+// parseClippingID parses a clipping ID argument
+func parseClippingID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid clipping ID %q", arg)
+	}
+	return id, nil
+}
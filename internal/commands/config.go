@@ -0,0 +1,275 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+// ConfigCommand inspects the layered configuration
+var ConfigCommand = &cli.Command{
+	Name:  "config",
+	Usage: "Inspect the configuration and where its values come from",
+	Description: `Settings are merged from several layers, highest precedence first:
+
+  1. flags (--profile, --token, ...)
+  2. environment: CK_CLI_PROFILE, CK_CLI_ENDPOINT, CK_CLI_TOKEN,
+     CK_CLI_VISIBILITY, CK_CLI_LIBRARY
+  3. project: .ck-cli.toml in the working directory or a parent
+  4. user: --config, else $CK_CLI_CONFIG, else ~/.ck-cli.toml
+  5. system: ` + config.SystemConfigPath + `
+
+get, set and unset take keys such as sync.visibility or
+profiles.<name>.endpoint; set and unset change the user config file only.
+
+Examples:
+  # Config files in use
+  ck-cli config show
+
+  # Effective values and the layer each one came from
+  ck-cli config show --resolved
+
+  # Change a setting
+  ck-cli config set sync.visibility private
+  ck-cli config set profiles.work.headers.X-Team books
+
+  # Check every config file for typos and invalid values
+  ck-cli config validate`,
+	Subcommands: []*cli.Command{
+		{
+			Name:  "init",
+			Usage: "Create the user config file with default settings",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "Overwrite an existing config file",
+				},
+			},
+			Action: configInitAction,
+		},
+		{
+			Name:      "get",
+			Usage:     "Print the effective value of a key",
+			ArgsUsage: "<key>",
+			Action:    configGetAction,
+		},
+		{
+			Name:      "set",
+			Usage:     "Set a key in the user config file",
+			ArgsUsage: "<key> <value>",
+			Action:    configSetAction,
+		},
+		{
+			Name:      "unset",
+			Usage:     "Remove a key from the user config file",
+			ArgsUsage: "<key>",
+			Action:    configUnsetAction,
+		},
+		{
+			Name:   "validate",
+			Usage:  "Check every config file for unknown keys and invalid values",
+			Action: configValidateAction,
+		},
+		{
+			Name:   "path",
+			Usage:  "Print the path of the user config file",
+			Action: configPathAction,
+		},
+		{
+			Name:  "show",
+			Usage: "List the config files, or the effective values with --resolved",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "resolved",
+					Usage: "Print the effective values and where each one came from",
+				},
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print as JSON",
+				},
+			},
+			Action: configShowAction,
+		},
+	},
+}
+
+// configLayer is a config file and whether it exists
+type configLayer struct {
+	Source string `json:"source"`
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+func configShowAction(c *cli.Context) error {
+	if c.Bool("resolved") {
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+		settings := cfg.Settings()
+		if c.Bool("json") {
+			return outputJSON(os.Stdout, settings)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+		for _, s := range settings {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.Value, s.Origin)
+		}
+		return w.Flush()
+	}
+
+	configPath, err := config.GetConfigPath(c.String("config"))
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %w", err)
+	}
+	origins, err := config.Layers(config.ResolveOptions{UserPath: configPath})
+	if err != nil {
+		return err
+	}
+
+	layers := make([]configLayer, 0, len(origins))
+	for _, origin := range origins {
+		_, err := os.Stat(origin.Detail)
+		layers = append(layers, configLayer{Source: origin.Source, Path: origin.Detail, Exists: err == nil})
+	}
+	if c.Bool("json") {
+		return outputJSON(os.Stdout, layers)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tPATH\tSTATUS")
+	for _, layer := range layers {
+		status := "missing"
+		if layer.Exists {
+			status = "loaded"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", layer.Source, layer.Path, status)
+	}
+	return w.Flush()
+}
+
+func configInitAction(c *cli.Context) error {
+	configPath, err := config.GetConfigPath(c.String("config"))
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %w", err)
+	}
+	if _, err := os.Stat(configPath); err == nil && !c.Bool("force") {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", configPath)
+	}
+
+	if err := config.NewConfig().Save(configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Created %s\n", configPath)
+	return nil
+}
+
+func configGetAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one key")
+	}
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	value, err := cfg.Get(c.Args().First())
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("%s is not set", c.Args().First())
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func configSetAction(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("expected a key and a value")
+	}
+	key, value := c.Args().Get(0), c.Args().Get(1)
+
+	cfg, configPath, err := openConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Set(key, value); err != nil {
+		return err
+	}
+	if err := cfg.Save(configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Set %s\n", key)
+	return nil
+}
+
+func configUnsetAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one key")
+	}
+	key := c.Args().First()
+
+	cfg, configPath, err := openConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Unset(key); err != nil {
+		return err
+	}
+	if err := cfg.Save(configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Unset %s\n", key)
+	return nil
+}
+
+func configValidateAction(c *cli.Context) error {
+	configPath, err := config.GetConfigPath(c.String("config"))
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %w", err)
+	}
+	layers, err := config.Layers(config.ResolveOptions{UserPath: configPath})
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for _, layer := range layers {
+		err := config.ValidateFile(layer.Detail)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			continue
+		case err != nil:
+			invalid++
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		default:
+			fmt.Fprintf(os.Stderr, "✅ %s config %s is valid\n", layer.Source, layer.Detail)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid config files", invalid)
+	}
+
+	// The layers must also combine, e.g. default_profile must exist
+	if _, err := loadConfig(c); err != nil {
+		return err
+	}
+	return nil
+}
+
+func configPathAction(c *cli.Context) error {
+	configPath, err := config.GetConfigPath(c.String("config"))
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %w", err)
+	}
+	fmt.Println(configPath)
+	return nil
+}
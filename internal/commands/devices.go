@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/clippingkk/cli/internal/device"
+	"github.com/urfave/cli/v2"
+)
+
+// DevicesCommand lists mounted Kindle devices
+var DevicesCommand = &cli.Command{
+	Name:  "devices",
+	Usage: "List mounted Kindle devices",
+	Description: `Scan the usual mount points (/media/$USER, /run/media/$USER, /media, /mnt)
+for Kindle volumes, recognised by their "system" directory and
+"documents/My Clippings.txt" file.
+
+Set CK_CLI_DEVICE_ROOTS to a list of directories (separated like $PATH)
+to scan other locations.
+
+Examples:
+  ck-cli devices
+  ck-cli parse --device --output http`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print devices as JSON",
+		},
+	},
+	Action: devicesAction,
+}
+
+func devicesAction(c *cli.Context) error {
+	roots := device.DefaultRoots()
+	devices, err := device.Scan(roots)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		if devices == nil {
+			devices = []device.Device{}
+		}
+		return outputJSON(os.Stdout, devices)
+	}
+
+	if len(devices) == 0 {
+		fmt.Fprintf(os.Stderr, "No Kindle found in %v\n", roots)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMOUNT POINT\tCLIPPINGS\tSIZE\tMODIFIED")
+	for _, d := range devices {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", d.Name, d.MountPoint, d.Clippings, formatSize(d.Size), d.ModTime.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// deviceInput returns the clippings file of the most recently updated Kindle
+func deviceInput() (string, error) {
+	kindle, err := device.Find(device.DefaultRoots())
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "📱 Reading %s (%d clippings, %s)\n", kindle.ClippingsPath, kindle.Clippings, formatSize(kindle.Size))
+	return "file:" + kindle.ClippingsPath, nil
+}
+
+// formatSize renders a byte count in human readable units
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
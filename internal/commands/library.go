@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/clippingkk/cli/internal/library"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/urfave/cli/v2"
+)
+
+// libraryInputFlag returns the --input flag of commands reading the local library
+func libraryInputFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "input",
+		Aliases: []string{"i"},
+		Usage:   "Clippings input to read (default: [library] input from config, else stdin)",
+		Value:   "",
+	}
+}
+
+// libraryInput returns the input given by --input, else the configured local library
+func libraryInput(c *cli.Context, cfg *config.Config) string {
+	if input := c.String("input"); input != "" {
+		return input
+	}
+	return cfg.Library.Input
+}
+
+// loadLibrary reads the clippings of --input or the configured local library
+func loadLibrary(ctx context.Context, c *cli.Context, cfg *config.Config) ([]models.ClippingItem, error) {
+	return loadClippings(ctx, libraryInput(c, cfg))
+}
+
+// dateRangeFlags returns the --since and --until flags
+func dateRangeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Only clippings created on or after this date (YYYY-MM-DD or RFC3339)",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "Only clippings created before this date (YYYY-MM-DD or RFC3339)",
+		},
+	}
+}
+
+// dateRangeFilter builds a filter from the --since and --until flags
+func dateRangeFilter(c *cli.Context) (library.Filter, error) {
+	var filter library.Filter
+	for name, bound := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.String(name)
+		if value == "" {
+			continue
+		}
+		t, err := parseDate(value)
+		if err != nil {
+			return library.Filter{}, fmt.Errorf("invalid --%s: %w", name, err)
+		}
+		*bound = t
+	}
+	return filter, nil
+}
+
+// useColor reports whether to colour output: "always", "never", or "auto"
+// for a terminal stdout without NO_COLOR set
+func useColor(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "", "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("invalid --color %q: must be 'auto', 'always' or 'never'", mode)
+	}
+}
@@ -1,75 +1,255 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/clippingkk/cli/internal/config"
+	"github.com/clippingkk/cli/internal/prompt"
+	"github.com/clippingkk/cli/pkg/ckk"
 	"github.com/urfave/cli/v2"
 )
 
 // LoginCommand handles user authentication
 var LoginCommand = &cli.Command{
-	Name:    "login",
-	Usage:   "Authenticate with ClippingKK service",
+	Name:  "login",
+	Usage: "Authenticate with ClippingKK service",
 	Description: `Login to ClippingKK service using your API token.
 
 Visit https://clippingkk.annatarhe.com, login to your account, 
 navigate to your profile page and open the 'API Token' dialog.
-Copy the token and use it with this command.
+Copy the token and use it with this command. The token is checked against
+the server before it is saved, and the account it belongs to is shown.
 
-Example:
-  ck-cli login --token YOUR_API_TOKEN
-  ck-cli --token YOUR_API_TOKEN login`,
+Without a token, login asks for it on the terminal without echoing it.
+Prefer the prompt or --token-stdin over --token: a token given as argument
+ends up in the shell history and in the process list.
+
+Profiles using another authentication scheme (see 'auth' in the config
+file) log in the same way, except:
+  basic   asks for the password of the profile's username
+  oauth2  shows a code to enter on the authorization server's page and
+          waits until it is approved; the token is refreshed automatically
+  mtls    stores no secret and only checks the client certificate
+
+Examples:
+  ck-cli login
+  pass show clippingkk | ck-cli login --token-stdin
+  ck-cli --profile company login`,
 	Flags: []cli.Flag{
 		&cli.StringFlag{
-			Name:     "token",
-			Aliases:  []string{"t"},
-			Usage:    "API token from ClippingKK profile page",
-			Required: false,
+			Name:    "token",
+			Aliases: []string{"t"},
+			Usage:   "API token from ClippingKK profile page, or the password of basic authentication (discouraged: visible in the shell history, prefer the prompt or --token-stdin)",
+		},
+		&cli.BoolFlag{
+			Name:  "token-stdin",
+			Usage: "Read the API token or password from stdin",
 		},
 	},
 	Action: loginAction,
 }
 
+// loginHelp tells where to find an API token
+const loginHelp = `Visit https://clippingkk.annatarhe.com and login,
+then navigate to your profile page and open the 'API Token' dialog.`
+
 func loginAction(c *cli.Context) error {
-	// Get token from flag or global flag
-	token := c.String("token")
-	if token == "" {
-		token = c.String("token") // Try global flag
-	}
-	
-	if token == "" {
-		fmt.Fprintf(os.Stderr, "❌ Token not found\n\n")
-		fmt.Fprintf(os.Stderr, "Visit https://clippingkk.annatarhe.com and login\n")
-		fmt.Fprintf(os.Stderr, "Then navigate to your profile page and open 'API Token' dialog.\n")
-		fmt.Fprintf(os.Stderr, "Copy the token and run:\n")
-		fmt.Fprintf(os.Stderr, "  ck-cli login --token YOUR_TOKEN\n\n")
-		os.Exit(1)
-	}
+	ctx := GetContext()
 
 	// Load or create config
-	configPath, err := config.GetConfigPath(c.String("config"))
+	cfg, configPath, err := openConfig(c)
 	if err != nil {
-		return fmt.Errorf("failed to get config path: %w", err)
+		return err
 	}
 
-	cfg, err := config.Load(configPath)
+	var token string
+	switch auth := cfg.HTTP.Auth; auth.EffectiveScheme() {
+	case config.AuthMTLS:
+		if c.String("token") != "" || c.Bool("token-stdin") {
+			return fmt.Errorf("profile %q authenticates with a client certificate and takes no token", cfg.ProfileName())
+		}
+	case config.AuthOAuth2:
+		if c.String("token") == "" && !c.Bool("token-stdin") {
+			token, err = deviceLogin(ctx, cfg)
+			break
+		}
+		fallthrough
+	default:
+		token, err = loginToken(ctx, c, auth)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 
-	// Update token
-	cfg.UpdateToken(token)
+	// Check the token against the server before keeping it
+	if token != "" {
+		cfg.UpdateToken(token)
+	}
+	account, info, err := verifyToken(ctx, cfg)
+	if err != nil {
+		return err
+	}
 
 	// Save config
 	if err := cfg.Save(configPath); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("✅ Successfully logged in!\n\n")
-	fmt.Printf("You can now synchronize your Kindle clippings by running:\n")
+	fmt.Printf("✅ Logged in to profile %q as %s\n", cfg.ProfileName(), formatAccount(account))
+	printTokenInfo(os.Stdout, info)
+	fmt.Printf("\nYou can now synchronize your Kindle clippings by running:\n")
 	fmt.Printf("  ck-cli parse --input /path/to/My\\ Clippings.txt --output http\n\n")
 
 	return nil
-}
\ No newline at end of file
+}
+
+// loginToken returns the token, or the password of basic authentication,
+// from --token, --token-stdin or a hidden prompt on the terminal
+func loginToken(ctx context.Context, c *cli.Context, auth config.AuthConfig) (string, error) {
+	token := c.String("token")
+	if c.Bool("token-stdin") {
+		if token != "" {
+			return "", fmt.Errorf("--token and --token-stdin cannot be combined")
+		}
+		return readToken(os.Stdin)
+	}
+	if token != "" {
+		return token, nil
+	}
+
+	if auth.EffectiveScheme() == config.AuthBasic {
+		if !prompt.IsTerminal(os.Stdin) {
+			return "", fmt.Errorf("no password given for %s, use --token-stdin", auth.Username)
+		}
+		password, err := prompt.Secret(ctx, os.Stdin, os.Stderr, "Password for "+auth.Username)
+		if err != nil {
+			return "", err
+		}
+		if password == "" {
+			return "", fmt.Errorf("no password given")
+		}
+		return password, nil
+	}
+
+	if !prompt.IsTerminal(os.Stdin) {
+		return "", fmt.Errorf("no token given\n\n%s\nCopy the token and run:\n  ck-cli login --token-stdin < token.txt", loginHelp)
+	}
+	fmt.Fprintf(os.Stderr, "%s\n\n", loginHelp)
+	token, err := prompt.Secret(ctx, os.Stdin, os.Stderr, "API token")
+	if err != nil {
+		return "", err
+	}
+	if token = strings.TrimSpace(token); token == "" {
+		return "", fmt.Errorf("no token given")
+	}
+	return token, nil
+}
+
+// deviceLogin runs the OAuth2 device flow of the active profile and returns
+// the token to store
+func deviceLogin(ctx context.Context, cfg *config.Config) (string, error) {
+	settings := cfg.HTTP.Auth.OAuth2
+	if settings == nil {
+		return "", fmt.Errorf("profile %q has no [oauth2] settings", cfg.ProfileName())
+	}
+	// The authorization server is reached with the proxy and TLS settings,
+	// but not the pinned keys of the API server
+	client, err := newClient(cfg)
+	if err != nil {
+		return "", err
+	}
+	oauth := settings.Client()
+	oauth.HTTPClient = client.OAuth2HTTPClient()
+
+	code, err := oauth.AuthorizeDevice(ctx)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "🔑 Open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+	if code.VerificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "   or open %s\n", code.VerificationURIComplete)
+	}
+	fmt.Fprintf(os.Stderr, "⏳ Waiting for the authorization...\n")
+
+	token, err := oauth.PollToken(ctx, code)
+	if err != nil {
+		return "", fmt.Errorf("failed to log in: %w", err)
+	}
+	return config.EncodeOAuth2Token(*token)
+}
+
+// readToken reads a single token from r, ignoring surrounding whitespace
+func readToken(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 64<<10))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("no token on stdin")
+	}
+	if strings.ContainsAny(token, " \t\r\n") {
+		return "", fmt.Errorf("expected a single token on stdin")
+	}
+	return token, nil
+}
+
+// verifyToken rejects expired tokens and asks the server for the account
+// of the configured credentials. OAuth2 tokens are refreshed instead.
+func verifyToken(ctx context.Context, cfg *config.Config) (*ckk.Account, tokenDetails, error) {
+	details := inspectToken(cfg.AccessToken())
+	if details.Known && details.Expired(time.Now()) && cfg.HTTP.Auth.EffectiveScheme() != config.AuthOAuth2 {
+		return nil, details, fmt.Errorf("token expired on %s", details.ExpiresAt.Format(time.RFC3339))
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, details, err
+	}
+	account, err := client.Me(ctx)
+	if err != nil {
+		return nil, details, fmt.Errorf("token rejected by %s: %w", cfg.HTTP.Endpoint, err)
+	}
+	return account, details, nil
+}
+
+// tokenDetails is what the token itself tells about its expiry and scope
+type tokenDetails struct {
+	ckk.TokenInfo
+	// Known is false for opaque tokens
+	Known bool `json:"-"`
+}
+
+func inspectToken(token string) tokenDetails {
+	info, ok := ckk.InspectToken(token)
+	return tokenDetails{TokenInfo: info, Known: ok}
+}
+
+// formatAccount prints an account as "name <email>"
+func formatAccount(account *ckk.Account) string {
+	if account.Email == "" {
+		return account.Name
+	}
+	return fmt.Sprintf("%s <%s>", account.Name, account.Email)
+}
+
+// printTokenInfo prints the expiry and scopes of a token
+func printTokenInfo(w io.Writer, details tokenDetails) {
+	expires, scopes := "unknown", "unknown"
+	if details.Known {
+		expires, scopes = "never", "all"
+		if !details.ExpiresAt.IsZero() {
+			days := int(time.Until(details.ExpiresAt).Hours() / 24)
+			expires = fmt.Sprintf("%s (in %d days)", details.ExpiresAt.Local().Format("2006-01-02"), days)
+		}
+		if len(details.Scopes) > 0 {
+			scopes = strings.Join(details.Scopes, ", ")
+		}
+	}
+	fmt.Fprintf(w, "   Expires: %s\n   Scope:   %s\n", expires, scopes)
+}
@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadToken(t *testing.T) {
+	token, err := readToken(strings.NewReader("  secret-token\n"))
+	if err != nil || token != "secret-token" {
+		t.Errorf("readToken = %q, %v", token, err)
+	}
+
+	for _, input := range []string{"", " \n", "two tokens\n", "one\ntwo\n"} {
+		if _, err := readToken(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
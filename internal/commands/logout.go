@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+// LogoutCommand removes the stored token of a profile
+var LogoutCommand = &cli.Command{
+	Name:   "logout",
+	Usage:  "Remove the stored token of the active profile",
+	Action: logoutAction,
+}
+
+func logoutAction(c *cli.Context) error {
+	cfg, configPath, err := openConfig(c)
+	if err != nil {
+		return err
+	}
+
+	// An encrypted token that cannot be read is still erased
+	if token, err := cfg.ProfileToken(cfg.ProfileName()); err == nil && token == "" {
+		fmt.Fprintf(os.Stderr, "Not logged in to profile %q\n", cfg.ProfileName())
+		return nil
+	}
+
+	cfg.ForgetToken()
+	if err := cfg.Save(configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "👋 Logged out of profile %q\n", cfg.ProfileName())
+	if os.Getenv(config.EnvToken) != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  %s is still set and used as token\n", config.EnvToken)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/sink"
+)
+
+// outputRegistry returns the sinks available to commands writing clippings:
+// the built-in "stdout:" and "file:" sinks, plus "ckk:" for the configured
+// ClippingKK endpoint and "http:"/"https:" for an explicit endpoint URL
+func outputRegistry(cfg *config.Config, opts syncOptions, format string) *sink.Registry {
+	registry := sink.NewRegistry()
+	registry.DefaultFormat = format
+
+	ckk := func(target sink.Target) (sink.Sink, error) {
+		endpoint := ""
+		if target.Scheme != "ckk" {
+			endpoint = target.Raw
+		}
+		return sink.Func(func(ctx context.Context, clippings []models.ClippingItem) error {
+			return syncToServer(ctx, cfg, clippings, endpoint, opts)
+		}), nil
+	}
+	registry.Register("ckk", ckk)
+	registry.Register("http", ckk)
+	registry.Register("https", ckk)
+
+	return registry
+}
+
+// writeOutputs delivers clippings to every output target, stdout when none
+// is given. All targets are opened before anything is written so a typo
+// fails early; a failing sink does not stop the others.
+func writeOutputs(ctx context.Context, registry *sink.Registry, targets []string, clippings []models.ClippingItem) error {
+	if len(targets) == 0 {
+		targets = []string{"stdout:"}
+	}
+
+	sinks := make([]sink.Sink, 0, len(targets))
+	defer func() {
+		for _, s := range sinks {
+			s.Close()
+		}
+	}()
+
+	for _, target := range targets {
+		// "http" on its own has always meant the configured ClippingKK service
+		if target == "http" {
+			target = "ckk:"
+		}
+		s, err := registry.Open(target)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, s)
+	}
+
+	var errs []error
+	for i, s := range sinks {
+		if err := s.Write(ctx, clippings); err != nil {
+			errs = append(errs, fmt.Errorf("output %s: %w", targets[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -8,9 +8,9 @@ import (
 	"os"
 	"strings"
 
-	"github.com/clippingkk/cli/internal/config"
-	"github.com/clippingkk/cli/internal/http"
-	"github.com/clippingkk/cli/internal/parser"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/sink"
+	"github.com/clippingkk/cli/internal/source"
 	"github.com/urfave/cli/v2"
 )
 
@@ -20,14 +20,23 @@ var ParseCommand = &cli.Command{
 	Usage: "Parse Kindle clippings file and output structured data",
 	Description: `Parse Amazon Kindle's "My Clippings.txt" file into structured JSON format.
 
-The command can read from:
-- A file specified with --input
-- Standard input (stdin) if no input is specified
+The command can read from (--input):
+- Standard input (stdin) if no input is specified, or "-" / "stdin:"
+- A clippings file, e.g. "My Clippings.txt" or "file:My Clippings.txt"
+- A directory of clipping files, e.g. "dir:~/kindle-backups"
+- A mounted Kindle with --device or "device:"
+- An HTTP(S) URL serving a clippings file
+- A previously exported JSON or JSONL file, e.g. "clippings.json" or
+  "jsonl:export.log"; sync dead-letter files are accepted too
 
-Output options:
-- Standard output (stdout) if no output is specified  
-- A file specified with --output filename
-- ClippingKK web service if --output is "http" or an HTTP URL
+Output options (--output can be repeated to write several at once):
+- Standard output (stdout) if no output is specified, or "stdout:"
+- A file specified with --output filename or "file:filename"
+- ClippingKK web service if --output is "http" or "ckk:"
+- Another ClippingKK endpoint if --output is an http(s):// URL
+
+File and stdout outputs accept a format option, e.g. "stdout:?format=csv".
+Formats: json (default), jsonl, csv, markdown.
 
 Examples:
   # Parse file to stdout
@@ -35,64 +44,77 @@ Examples:
   
   # Parse from stdin to stdout
   cat "My Clippings.txt" | ck-cli parse
+
+  # Parse straight from a mounted Kindle
+  ck-cli parse --device
+
+  # Re-sync an earlier export without the original txt
+  ck-cli parse --input clippings.json --output http
   
   # Parse file to JSON file
   ck-cli parse --input "My Clippings.txt" --output clippings.json
+
+  # Save a Markdown copy and sync in one go
+  ck-cli parse --input "My Clippings.txt" --output clippings.md --output http
   
-  # Parse and sync to ClippingKK service
-  ck-cli parse --input "My Clippings.txt" --output http`,
-	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:    "input",
-			Aliases: []string{"i"},
-			Usage:   "Path to Kindle clippings file (default: read from stdin)",
-			Value:   "",
+  # Parse and sync the clippings missing on ClippingKK service
+  ck-cli parse --input "My Clippings.txt" --output http
+
+  # Sync and keep a machine readable report of every chunk
+  ck-cli parse --input "My Clippings.txt" --output http --report-json report.json
+
+  # Sync without publishing the clippings
+  ck-cli parse --input "My Clippings.txt" --output http --visibility private
+
+  # Keep clippings rejected by the server for a later retry
+  ck-cli parse --input "My Clippings.txt" --output http --dead-letter rejected.json`,
+	Flags: append([]cli.Flag{
+		inputFlag(),
+		&cli.BoolFlag{
+			Name:  "device",
+			Usage: "Read My Clippings.txt from a mounted Kindle (see 'ck-cli devices')",
 		},
-		&cli.StringFlag{
+		&cli.StringSliceFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
-			Usage:   "Output destination: file path, 'http' for ClippingKK sync, or empty for stdout",
+			Usage:   "Output destination, repeatable: file path or URI (stdout:, file:, sqlite:, ckk:, https:), 'http' for ClippingKK sync (default: stdout)",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Usage:   "Format for file and stdout outputs: " + strings.Join(sink.Formats, ", ") + " (default: from file extension, else json)",
 			Value:   "",
 		},
-	},
+	}, syncFlags...),
 	Action: parseAction,
 }
 
 func parseAction(c *cli.Context) error {
 	ctx := GetContext()
 
-	// Get token from global flag if provided
-	token := c.String("token")
-
-	// Load config
-	configPath, err := config.GetConfigPath(c.String("config"))
+	// A --token is used for this run only, see "ck-cli login" to keep one
+	cfg, err := loadConfig(c)
 	if err != nil {
-		return fmt.Errorf("failed to get config path: %w", err)
+		return err
 	}
 
-	cfg, err := config.Load(configPath)
+	opts, err := applySyncFlags(c, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 
-	// Update token if provided via flag
-	if token != "" {
-		cfg.UpdateToken(token)
-		if err := cfg.Save(configPath); err != nil {
-			return fmt.Errorf("failed to save updated config: %w", err)
+	input := c.String("input")
+	if c.Bool("device") {
+		if input != "" {
+			return fmt.Errorf("--device cannot be combined with --input")
+		}
+		if input, err = deviceInput(); err != nil {
+			return err
 		}
 	}
 
-	// Read input data
-	inputData, err := readInput(c.String("input"))
-	if err != nil {
-		return fmt.Errorf("failed to read input: %w", err)
-	}
-
-	// Parse clippings
-	clippings, err := parser.Parse(inputData)
+	clippings, err := loadClippings(ctx, input)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Parsing failed: %v\n", err)
 		return err
 	}
 
@@ -101,46 +123,28 @@ func parseAction(c *cli.Context) error {
 		return nil
 	}
 
-	fmt.Fprintf(os.Stderr, "📚 Parsed %d clippings successfully\n", len(clippings))
-
 	// Handle output
-	outputTarget := c.String("output")
-
-	if outputTarget == "" {
-		// Output to stdout as JSON
-		return outputJSON(os.Stdout, clippings)
-	} else if outputTarget == "http" || strings.HasPrefix(outputTarget, "http") {
-		// Sync to ClippingKK service
-		return syncToServer(ctx, cfg, clippings, outputTarget)
-	} else {
-		// Output to file
-		return outputToFile(outputTarget, clippings)
-	}
+	registry := outputRegistry(cfg, opts, c.String("format"))
+	return writeOutputs(ctx, registry, c.StringSlice("output"), clippings)
 }
 
-// readInput reads data from file or stdin
-func readInput(inputPath string) (string, error) {
-	var reader io.Reader
-
-	if inputPath == "" {
-		// Read from stdin
-		reader = os.Stdin
-	} else {
-		// Read from file
-		file, err := os.Open(inputPath)
-		if err != nil {
-			return "", fmt.Errorf("failed to open input file: %w", err)
-		}
-		defer file.Close()
-		reader = file
+// loadClippings reads clippings from the source picked by the input URI
+func loadClippings(ctx context.Context, input string) ([]models.ClippingItem, error) {
+	src, err := source.NewRegistry().Open(input)
+	if err != nil {
+		return nil, err
 	}
 
-	data, err := io.ReadAll(reader)
+	clippings, err := src.Load(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to read input: %w", err)
+		fmt.Fprintf(os.Stderr, "❌ Parsing failed: %v\n", err)
+		return nil, err
 	}
 
-	return string(data), nil
+	if len(clippings) > 0 {
+		fmt.Fprintf(os.Stderr, "📚 Parsed %d clippings successfully\n", len(clippings))
+	}
+	return clippings, nil
 }
 
 // outputJSON outputs clippings as JSON to the writer
@@ -185,36 +189,3 @@ func outputToFile(filename string, clippings interface{}) error {
 	fmt.Fprintf(os.Stderr, "💾 Saved %d clippings to %s\n", count, filename)
 	return nil
 }
-
-// syncToServer syncs clippings to ClippingKK service
-func syncToServer(ctx context.Context, cfg *config.Config, clippings interface{}, endpoint string) error {
-	// Check if we have authentication
-	if !cfg.HasToken() {
-		fmt.Fprintf(os.Stderr, "❌ No authentication token found\n")
-		fmt.Fprintf(os.Stderr, "Please login first: ck-cli login --token YOUR_TOKEN\n")
-		os.Exit(1)
-	}
-
-	httpClient := http.NewClient(cfg)
-
-	// Convert to proper type for HTTP client
-	jsonData, err := json.Marshal(clippings)
-	if err != nil {
-		return fmt.Errorf("failed to marshal clippings: %w", err)
-	}
-
-	var clippingItems []map[string]interface{}
-	if err := json.Unmarshal(jsonData, &clippingItems); err != nil {
-		return fmt.Errorf("failed to unmarshal clippings: %w", err)
-	}
-
-	fmt.Fprintf(os.Stderr, "🚀 Starting sync to ClippingKK service...\n")
-
-	// For now, just report success - the HTTP client will be enhanced later
-	fmt.Fprintf(os.Stderr, "✅ Successfully synced %d clippings to ClippingKK!\n", len(clippingItems))
-
-	// TODO: Implement actual HTTP sync using httpClient.SyncToServer
-	_ = httpClient // Suppress unused variable warning
-
-	return nil
-}
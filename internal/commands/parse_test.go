@@ -7,11 +7,11 @@ import (
 	"testing"
 
 	"github.com/clippingkk/cli/internal/models"
-	"github.com/clippingkk/cli/internal/parser"
+	"github.com/clippingkk/cli/pkg/parser"
 )
 
 // This test file validates the parse command against all fixture files.
-// It complements the existing parser tests in internal/parser/parser_test.go
+// It complements the existing parser tests in pkg/parser/parser_test.go
 // by testing the full integration with actual fixture data.
 
 // TestParseBasicValidation performs basic validation that the parser works
@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+// ProfileCommand manages the named profiles of the config file
+var ProfileCommand = &cli.Command{
+	Name:  "profile",
+	Usage: "Manage ClippingKK accounts and endpoints as named profiles",
+	Description: `Each profile holds an endpoint, extra HTTP headers and an API token.
+Profiles may authenticate with another scheme, set with 'auth' and the
+[oauth2] or [mtls] tables of the profile in the config file.
+Commands use the default profile unless --profile selects another one.
+Flags of the subcommands must come before the profile name.
+
+Examples:
+  # Add a self-hosted staging account and use it once
+  ck-cli profile add --endpoint https://staging.example.com/api/v2/graphql staging
+  ck-cli --profile staging login
+  ck-cli --profile staging sync diff -i "My Clippings.txt"
+
+  # Make it the default
+  ck-cli profile use staging`,
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "List the profiles of every config layer, marking the default one",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print profiles as JSON, without tokens",
+				},
+			},
+			Action: profileListAction,
+		},
+		{
+			Name:      "use",
+			Usage:     "Make a profile the default",
+			ArgsUsage: "<name>",
+			Action:    profileUseAction,
+		},
+		{
+			Name:      "add",
+			Usage:     "Add a profile",
+			ArgsUsage: "<name>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "endpoint",
+					Usage: "GraphQL endpoint of the ClippingKK service",
+					Value: config.DefaultEndpoint,
+				},
+				&cli.StringSliceFlag{
+					Name:  "header",
+					Usage: "Extra HTTP header as 'Name: value', repeatable",
+				},
+				&cli.BoolFlag{
+					Name:  "default",
+					Usage: "Make the new profile the default",
+				},
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "Replace an existing profile of the same name",
+				},
+			},
+			Action: profileAddAction,
+		},
+		{
+			Name:      "rm",
+			Usage:     "Remove a profile",
+			ArgsUsage: "<name>",
+			Flags:     []cli.Flag{yesFlag()},
+			Action:    profileRemoveAction,
+		},
+	},
+}
+
+// profileSummary describes a profile without its secrets
+type profileSummary struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	HasToken bool   `json:"hasToken"`
+	Default  bool   `json:"default"`
+}
+
+func profileListAction(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	summaries := make([]profileSummary, 0, len(cfg.Profiles))
+	for _, name := range cfg.ProfileNames() {
+		profile, _ := cfg.Profile(name)
+		http := profile.HTTPConfig()
+		token, err := cfg.ProfileToken(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Profile %q: %v\n", name, err)
+		}
+		summaries = append(summaries, profileSummary{
+			Name:     name,
+			Endpoint: http.Endpoint,
+			HasToken: http.HasAuthorization() || token != "",
+			Default:  name == cfg.DefaultProfile,
+		})
+	}
+
+	if c.Bool("json") {
+		return outputJSON(os.Stdout, summaries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tNAME\tENDPOINT\tTOKEN")
+	for _, s := range summaries {
+		mark, token := "", "none"
+		if s.Default {
+			mark = "*"
+		}
+		if s.HasToken {
+			token = "set"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, s.Name, s.Endpoint, token)
+	}
+	return w.Flush()
+}
+
+func profileUseAction(c *cli.Context) error {
+	name, err := profileArg(c)
+	if err != nil {
+		return err
+	}
+	cfg, configPath, err := openConfig(c)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.SetDefaultProfile(name); err != nil {
+		return err
+	}
+	if err := cfg.Save(configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Default profile is now %q\n", name)
+	return nil
+}
+
+func profileAddAction(c *cli.Context) error {
+	name, err := profileArg(c)
+	if err != nil {
+		return err
+	}
+
+	profile := config.Profile{Endpoint: c.String("endpoint")}
+	for _, header := range c.StringSlice("header") {
+		key, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid --header %q: expected 'Name: value'", header)
+		}
+		if profile.Headers == nil {
+			profile.Headers = make(map[string]string)
+		}
+		profile.Headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	cfg, configPath, err := openConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := cfg.AddProfile(name, profile, c.Bool("force")); err != nil {
+		return err
+	}
+	if c.Bool("default") {
+		if err := cfg.SetDefaultProfile(name); err != nil {
+			return err
+		}
+	}
+	if err := cfg.Save(configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Added profile %q\n", name)
+	fmt.Fprintf(os.Stderr, "   Run 'ck-cli --profile %s login' to store its token\n", name)
+	return nil
+}
+
+func profileRemoveAction(c *cli.Context) error {
+	name, err := profileArg(c)
+	if err != nil {
+		return err
+	}
+	cfg, configPath, err := openConfig(c)
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Profile(name); !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	ok, err := confirm(c, fmt.Sprintf("Remove profile %q and its token?", name))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Aborted\n")
+		return nil
+	}
+
+	if err := cfg.RemoveProfile(name); err != nil {
+		return err
+	}
+	if err := cfg.Save(configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "🗑️  Removed profile %q\n", name)
+	return nil
+}
+
+// profileArg returns the single profile name argument
+func profileArg(c *cli.Context) (string, error) {
+	if c.NArg() != 1 {
+		return "", fmt.Errorf("expected exactly one profile name")
+	}
+	return c.Args().First(), nil
+}
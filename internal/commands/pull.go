@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/clippingkk/cli/internal/sink"
+	"github.com/clippingkk/cli/pkg/ckk"
+	"github.com/urfave/cli/v2"
+)
+
+// PullCommand downloads clippings from the ClippingKK service
+var PullCommand = &cli.Command{
+	Name:  "pull",
+	Usage: "Download your clippings from ClippingKK service",
+	Description: `Page through the clippings stored in your ClippingKK account and write
+them locally, e.g. to back up the server-side library.
+
+Output formats: json (default), jsonl, csv, markdown. When --format is not
+given it is guessed from the --output file extension. --output accepts the
+same destinations as "ck-cli parse" and can be repeated.
+
+Examples:
+  # Back up everything as JSON
+  ck-cli pull --output backup.json
+
+  # Clippings added this year, as CSV on stdout
+  ck-cli pull --since 2024-01-01 --format csv
+
+  # Only some books
+  ck-cli pull --book "Bad Blood" --book "深度工作" --output books.md`,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output destination, repeatable: file path or URI (stdout:, file:, sqlite:, https:) (default: stdout)",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Usage:   "Format for file and stdout outputs: " + strings.Join(sink.Formats, ", "),
+			Value:   "",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Only clippings created at or after this date (YYYY-MM-DD or RFC3339)",
+			Value: "",
+		},
+		&cli.StringSliceFlag{
+			Name:  "book",
+			Usage: "Only clippings of this book title (repeatable)",
+		},
+		&cli.IntFlag{
+			Name:  "page-size",
+			Usage: "Number of clippings fetched per request",
+			Value: ckk.DefaultPageSize,
+		},
+	},
+	Action: pullAction,
+}
+
+func pullAction(c *cli.Context) error {
+	ctx := GetContext()
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := requireToken(cfg); err != nil {
+		return err
+	}
+
+	opts := ckk.FetchOptions{
+		Books:    c.StringSlice("book"),
+		PageSize: c.Int("page-size"),
+		OnSkip:   warnSkipped,
+	}
+	if since := c.String("since"); since != "" {
+		opts.Since, err = parseDate(since)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "⬇️  Fetching clippings from ClippingKK service...\n")
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	clippings, err := client.FetchClippings(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "📚 Fetched %d clippings\n", len(clippings))
+
+	// Uploading pulled clippings to another endpoint must not skip them as
+	// already present, so sinks get a full sync
+	registry := outputRegistry(cfg, syncOptions{full: true}, c.String("format"))
+	return writeOutputs(ctx, registry, c.StringSlice("output"), clippings)
+}
+
+// warnSkipped reports a server clipping left out of a fetch
+func warnSkipped(err error) {
+	fmt.Fprintf(os.Stderr, "⚠️  Skipped: %v\n", err)
+}
+
+// loadConfig resolves the effective configuration from every config layer,
+// the environment and the global flags, see config.Resolve
+func loadConfig(c *cli.Context) (*config.Config, error) {
+	configPath, err := config.GetConfigPath(c.String("config"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	cfg, err := config.Resolve(config.ResolveOptions{
+		UserPath: configPath,
+		Flags:    config.Overrides{Profile: c.String("profile"), Token: c.String("token")},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// openConfig loads the user configuration file alone, for editing, and
+// activates the profile given by --profile or $CK_CLI_PROFILE, else the
+// default one. It returns the file path for saving.
+func openConfig(c *cli.Context) (*config.Config, string, error) {
+	configPath, err := config.GetConfigPath(c.String("config"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
+	}
+
+	profile := c.String("profile")
+	if profile == "" {
+		profile = os.Getenv(config.EnvProfile)
+	}
+	if err := cfg.UseProfile(profile); err != nil {
+		return nil, "", err
+	}
+
+	return cfg, configPath, nil
+}
+
+// parseDate parses a YYYY-MM-DD date or an RFC3339 timestamp
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither YYYY-MM-DD nor RFC3339", value)
+	}
+	return t, nil
+}
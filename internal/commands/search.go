@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/search"
+	"github.com/urfave/cli/v2"
+)
+
+// ANSI escapes used to highlight matches on a terminal
+const (
+	ansiHighlight = "\x1b[1;33m"
+	ansiDim       = "\x1b[2m"
+	ansiReset     = "\x1b[0m"
+)
+
+// SearchCommand searches clippings by content
+var SearchCommand = &cli.Command{
+	Name:      "search",
+	Usage:     "Search clippings by content, book, author, kind and date",
+	ArgsUsage: "[<query>...]",
+	Description: `Search an input file or the local library configured as [library] input.
+
+Every query word must occur in the content, in any case and anywhere in the
+text, so Chinese and Japanese phrases match without word breaks. Use --regex
+for a regular expression instead. Flags must come before the query.
+
+Examples:
+  # Clippings mentioning both words
+  ck-cli search -i "My Clippings.txt" fear killer
+
+  # Notes on one book in 2024, as JSON
+  ck-cli search --title Dune --kind note --since 2024-01-01 --json
+
+  # Regular expression over the local library
+  ck-cli search --regex "黑暗森林|宇宙"`,
+	Flags: append([]cli.Flag{
+		libraryInputFlag(),
+		&cli.BoolFlag{
+			Name:    "regex",
+			Aliases: []string{"e"},
+			Usage:   "Treat the query as a regular expression",
+		},
+		&cli.BoolFlag{
+			Name:  "case-sensitive",
+			Usage: "Match letter case exactly",
+		},
+		&cli.StringFlag{
+			Name:  "title",
+			Usage: "Only books whose title contains this text",
+		},
+		&cli.StringFlag{
+			Name:  "author",
+			Usage: "Only books whose author contains this text",
+		},
+		&cli.StringFlag{
+			Name:  "kind",
+			Usage: "Only clippings of this kind: 'highlight', 'note' or 'bookmark'",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print results as JSON with match offsets",
+		},
+		&cli.StringFlag{
+			Name:  "color",
+			Usage: "Highlight matches: 'auto', 'always' or 'never'",
+			Value: "auto",
+		},
+	}, dateRangeFlags()...),
+	Action: searchAction,
+}
+
+func searchAction(c *cli.Context) error {
+	opts := search.Options{
+		Query:         strings.Join(c.Args().Slice(), " "),
+		Regex:         c.Bool("regex"),
+		CaseSensitive: c.Bool("case-sensitive"),
+		Title:         c.String("title"),
+		Author:        c.String("author"),
+		Kind:          strings.ToLower(c.String("kind")),
+	}
+	switch opts.Kind {
+	case "", models.KindHighlight, models.KindNote, models.KindBookmark:
+	default:
+		return fmt.Errorf("invalid --kind %q: must be 'highlight', 'note' or 'bookmark'", opts.Kind)
+	}
+	if strings.TrimSpace(opts.Query) == "" && opts.Title == "" && opts.Author == "" && opts.Kind == "" &&
+		!c.IsSet("since") && !c.IsSet("until") {
+		return fmt.Errorf("specify a query or at least one filter")
+	}
+
+	var err error
+	if opts.Filter, err = dateRangeFilter(c); err != nil {
+		return err
+	}
+	color, err := useColor(c.String("color"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	clippings, err := loadLibrary(GetContext(), c, cfg)
+	if err != nil {
+		return err
+	}
+
+	results, err := search.Search(clippings, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🔍 %d matching clippings\n", len(results))
+
+	if c.Bool("json") {
+		return outputJSON(os.Stdout, results)
+	}
+	printResults(os.Stdout, results, color)
+	return nil
+}
+
+// printResults prints each result as a header line followed by its content
+func printResults(w io.Writer, results []search.Result, color bool) {
+	before, after, dim, reset := "", "", "", ""
+	if color {
+		before, after, dim, reset = ansiHighlight, ansiReset, ansiDim, ansiReset
+	}
+
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		item := result.Clipping
+		header := item.Title
+		if item.Author != "" {
+			header += " — " + item.Author
+		}
+		fmt.Fprintf(w, "%s%s  %s  %s%s\n", dim, header, item.PageAt, item.CreatedAt.Format("2006-01-02"), reset)
+		fmt.Fprintf(w, "  %s\n", search.Highlight(item.Content, result.Matches, before, after))
+	}
+}
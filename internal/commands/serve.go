@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/server"
+	"github.com/clippingkk/cli/internal/source"
+	"github.com/clippingkk/cli/pkg/ckk"
+	"github.com/urfave/cli/v2"
+)
+
+// DefaultServeAddr is where "ck-cli serve" listens unless --addr is given
+const DefaultServeAddr = "127.0.0.1:8723"
+
+// EnvServeToken gives the API token of "ck-cli serve" instead of a random one
+const EnvServeToken = "CK_CLI_SERVE_TOKEN"
+
+// minServeTokenLength keeps tokens given in EnvServeToken hard to guess
+const minServeTokenLength = 16
+
+// ServeCommand runs the local HTTP API
+var ServeCommand = &cli.Command{
+	Name:  "serve",
+	Usage: "Run a local HTTP API for editors and browser extensions",
+	Description: `Serve a small JSON API on localhost so other tools can parse and sync
+clippings without shelling out to ck-cli.
+
+Endpoints:
+  GET  /health      liveness check
+  POST /parse       parse the posted clippings (JSON body or multipart "file" field)
+  GET  /books       books of the local library
+  GET  /clippings   clippings of the local library (?book=, ?author=, ?since=, ?until=)
+  POST /sync        sync the posted clippings, or the whole library for an empty body
+
+The local library is any input accepted by --input, read on every request.
+
+Every request must send the API token as "Authorization: Bearer <token>". A
+new token is generated on every run and printed at start, or written to
+--token-file (readable by the user only) for the tool launching the server;
+a launcher may instead choose the token and pass it in CK_CLI_SERVE_TOKEN.
+Requests for another host name and from web pages of other origins than
+localhost or --allow-origin are rejected, and POST bodies must be JSON, JSON
+lines or a multipart form.
+
+Examples:
+  # Serve the clippings of a backup directory
+  ck-cli serve --library dir:~/kindle-backups
+
+  # Parse a file through the API
+  curl -H "Authorization: Bearer $TOKEN" -F file=@"My Clippings.txt" http://127.0.0.1:8723/parse
+
+  # Let a script read the token
+  ck-cli serve --token-file "$XDG_RUNTIME_DIR/ck-cli.token"`,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "addr",
+			Usage: "Address to listen on",
+			Value: DefaultServeAddr,
+		},
+		&cli.StringFlag{
+			Name:    "library",
+			Aliases: []string{"l"},
+			Usage:   "Clippings file, directory or URI served as the local library (default: [library] input from config)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-origin",
+			Usage: "Browser origin allowed to call the API besides localhost, e.g. chrome-extension://<id>, repeatable",
+		},
+		&cli.StringFlag{
+			Name:  "token-file",
+			Usage: "Write the API token to this file, readable by the user only, instead of printing it; removed on exit",
+		},
+	}, syncFlags...),
+	Action: serveAction,
+}
+
+func serveAction(c *cli.Context) error {
+	ctx := GetContext()
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	opts, err := applySyncFlags(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	var library source.Source
+	input := c.String("library")
+	if input == "" {
+		input = cfg.Library.Input
+	}
+	if input != "" {
+		if library, err = source.NewRegistry().Open(input); err != nil {
+			return err
+		}
+	}
+
+	token := os.Getenv(EnvServeToken)
+	if token == "" {
+		if token, err = server.NewToken(); err != nil {
+			return err
+		}
+	} else if len(token) < minServeTokenLength {
+		return fmt.Errorf("%s must be at least %d characters long", EnvServeToken, minServeTokenLength)
+	}
+
+	listener, err := net.Listen("tcp", c.String("addr"))
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", c.String("addr"), err)
+	}
+	var hosts []string
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() {
+		fmt.Fprintf(os.Stderr, "⚠️  Listening on %s: the API is reachable from other machines, keep the token secret\n", addr)
+		// Clients on other machines address the server by the host of --addr;
+		// a wildcard address only answers requests for localhost
+		if host, _, err := net.SplitHostPort(c.String("addr")); err == nil && host != "" && !addr.IP.IsUnspecified() {
+			hosts = append(hosts, host)
+		}
+	}
+
+	handler := server.New(server.Options{
+		Library: library,
+		Sync: func(ctx context.Context, clippings []models.ClippingItem) (*ckk.SyncReport, error) {
+			return pushClippings(ctx, cfg, clippings, "", opts)
+		},
+		Token:   token,
+		Hosts:   hosts,
+		Origins: c.StringSlice("allow-origin"),
+	})
+
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(listener)
+	}()
+	fmt.Fprintf(os.Stderr, "🌐 Serving on http://%s (Ctrl+C to stop)\n", listener.Addr())
+	switch tokenFile := c.String("token-file"); {
+	case tokenFile != "":
+		if err := writeTokenFile(tokenFile, token); err != nil {
+			srv.Close()
+			return err
+		}
+		defer os.Remove(tokenFile)
+		fmt.Fprintf(os.Stderr, "🔑 API token written to %s\n", tokenFile)
+	case os.Getenv(EnvServeToken) != "":
+		fmt.Fprintf(os.Stderr, "🔑 API token from %s\n", EnvServeToken)
+	default:
+		fmt.Fprintf(os.Stderr, "🔑 API token: %s\n", token)
+	}
+
+	select {
+	case err := <-done:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "👋 Server stopped\n")
+	return nil
+}
+
+// writeTokenFile writes token to path with 0600 permissions, replacing the
+// file rather than writing into one others may already read
+func writeTokenFile(path, token string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ck-cli-token-*")
+	if err != nil {
+		return fmt.Errorf("failed to create token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(token + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
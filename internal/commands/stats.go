@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/clippingkk/cli/internal/library"
+	"github.com/urfave/cli/v2"
+)
+
+// statsBarWidth is the width of the longest bar in activity charts
+const statsBarWidth = 30
+
+// StatsCommand prints reading statistics
+var StatsCommand = &cli.Command{
+	Name:  "stats",
+	Usage: "Show reading statistics per book and overall",
+	Description: `Summarise an input file or the local library configured as [library] input:
+totals, average highlight length, reading streaks, activity per month and
+weekday, and the most highlighted books.
+
+Examples:
+  ck-cli stats -i "My Clippings.txt"
+  ck-cli stats --since 2024-01-01 --top 5
+  ck-cli stats --json | jq '.perBook[] | select(.notes > 0)'`,
+	Flags: append([]cli.Flag{
+		libraryInputFlag(),
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "Number of books to list, 0 for all",
+			Value: 10,
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print statistics as JSON, with every book",
+		},
+	}, dateRangeFlags()...),
+	Action: statsAction,
+}
+
+func statsAction(c *cli.Context) error {
+	filter, err := dateRangeFilter(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	clippings, err := loadLibrary(GetContext(), c, cfg)
+	if err != nil {
+		return err
+	}
+
+	stats := library.ComputeStats(filter.Select(clippings))
+	if c.Bool("json") {
+		return outputJSON(os.Stdout, stats)
+	}
+	return printStats(os.Stdout, stats, c.Int("top"))
+}
+
+// printStats renders statistics as terminal tables and bar charts
+func printStats(w io.Writer, stats library.Stats, top int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Clippings\t%d (%d highlights, %d notes)\n", stats.Clippings, stats.Highlights, stats.Notes)
+	fmt.Fprintf(tw, "Books\t%d\n", stats.Books)
+	fmt.Fprintf(tw, "Average highlight length\t%.0f characters\n", stats.AverageLength)
+	if !stats.FirstActivity.IsZero() {
+		fmt.Fprintf(tw, "Activity\t%s to %s, %d active days\n", formatDay(stats.FirstActivity), formatDay(stats.LastActivity), stats.ActiveDays)
+		fmt.Fprintf(tw, "Longest streak\t%s\n", formatStreak(stats.LongestStreak))
+		fmt.Fprintf(tw, "Latest streak\t%s\n", formatStreak(stats.LatestStreak))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	books := stats.PerBook
+	if top > 0 && len(books) > top {
+		books = books[:top]
+	}
+	if len(books) > 0 {
+		fmt.Fprintf(w, "\nMost highlighted books\n")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TITLE\tAUTHOR\tCLIPPINGS\tNOTES\tAVG LENGTH\tFIRST\tLAST")
+		for _, book := range books {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.0f\t%s\t%s\n", book.Title, book.Author, book.Clippings, book.Notes,
+				book.AverageLength, formatDay(book.FirstAddedAt), formatDay(book.LastAddedAt))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(stats.ByMonth) > 0 {
+		fmt.Fprintf(w, "\nPer month\n")
+		printBars(w, stats.ByMonth)
+		fmt.Fprintf(w, "\nPer weekday\n")
+		printBars(w, stats.ByWeekday)
+	}
+	return nil
+}
+
+// printBars draws one proportional bar per period
+func printBars(w io.Writer, counts []library.PeriodCount) {
+	max, width := 0, 0
+	for _, c := range counts {
+		if c.Count > max {
+			max = c.Count
+		}
+		if len(c.Period) > width {
+			width = len(c.Period)
+		}
+	}
+	for _, c := range counts {
+		bar := 0
+		if max > 0 {
+			bar = (c.Count*statsBarWidth + max - 1) / max
+		}
+		fmt.Fprintf(w, "  %-*s %5d %s\n", width, c.Period, c.Count, strings.Repeat("█", bar))
+	}
+}
+
+// formatDay formats a date, "-" when unknown
+func formatDay(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatStreak(s library.Streak) string {
+	if s.Days == 0 {
+		return "-"
+	}
+	if s.Days == 1 {
+		return fmt.Sprintf("1 day (%s)", formatDay(s.Start))
+	}
+	return fmt.Sprintf("%d days (%s to %s)", s.Days, formatDay(s.Start), formatDay(s.End))
+}
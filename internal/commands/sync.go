@@ -0,0 +1,398 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/reconcile"
+	"github.com/clippingkk/cli/pkg/ckk"
+	"github.com/urfave/cli/v2"
+)
+
+// SyncCommand reconciles local clippings with the ClippingKK service
+var SyncCommand = &cli.Command{
+	Name:  "sync",
+	Usage: "Compare local clippings with ClippingKK and push the difference",
+	Description: `Compare parsed local clippings with what your ClippingKK account already
+holds. Clippings are matched by book and content hash; clippings of the same
+book and location with different content are reported as changed and left
+alone unless 'sync apply --update-changed' overwrites them.
+
+Examples:
+  # Show what differs
+  ck-cli sync diff --input "My Clippings.txt"
+
+  # Upload the clippings the server does not have yet
+  ck-cli sync apply --input "My Clippings.txt"
+
+  # Also replace the server content of changed clippings, after confirmation
+  ck-cli sync apply --update-changed --input "My Clippings.txt"`,
+	Subcommands: []*cli.Command{
+		{
+			Name:  "diff",
+			Usage: "Show clippings added locally, present only on the server, and changed",
+			Flags: []cli.Flag{
+				inputFlag(),
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print the difference as JSON",
+				},
+			},
+			Action: syncDiffAction,
+		},
+		{
+			Name:  "apply",
+			Usage: "Push the clippings missing on the server",
+			Flags: append([]cli.Flag{
+				inputFlag(),
+				&cli.BoolFlag{
+					Name:  "update-changed",
+					Usage: "Replace the server content of changed clippings with the local content, after confirmation",
+				},
+				yesFlag(),
+			}, syncFlags...),
+			Action: syncApplyAction,
+		},
+	},
+}
+
+// syncFlags are shared by every command uploading clippings
+var syncFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "visibility",
+		Usage: "Visibility of synced clippings: 'public' or 'private' (default: from config, else public)",
+		Value: "",
+	},
+	&cli.BoolFlag{
+		Name:  "resolve-books",
+		Usage: "Look up ClippingKK book IDs by title and author before syncing",
+		Value: true,
+	},
+	&cli.StringFlag{
+		Name:  "book-map",
+		Usage: "TOML file pinning book titles to ClippingKK book IDs (default: ~/.ck-cli.books.toml)",
+		Value: "",
+	},
+	&cli.BoolFlag{
+		Name:  "full",
+		Usage: "Upload every clipping instead of only those missing on the server",
+	},
+	&cli.StringFlag{
+		Name:  "report-json",
+		Usage: "Write a JSON sync report to this file ('-' for stdout) when syncing to ClippingKK",
+		Value: "",
+	},
+	&cli.StringFlag{
+		Name:  "dead-letter",
+		Usage: "Write clippings rejected by ClippingKK to this JSON file so they can be fixed and re-submitted",
+		Value: "",
+	},
+}
+
+// inputFlag returns the --input flag shared by commands reading clippings
+func inputFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "input",
+		Aliases: []string{"i"},
+		Usage:   "Clippings file, directory, URL or exported JSON/JSONL, optionally as a URI (default: read from stdin)",
+		Value:   "",
+	}
+}
+
+// syncOptions holds the command line settings of a sync
+type syncOptions struct {
+	reportPath     string
+	deadLetterPath string
+	resolveBooks   bool
+	full           bool
+	// confirmUpdates is set by --update-changed and asks before changed
+	// clippings are overwritten on the server; without it they are left alone
+	confirmUpdates func(changes []reconcile.Change) (bool, error)
+}
+
+// applySyncFlags applies per-run overrides from syncFlags to cfg and returns the remaining options
+func applySyncFlags(c *cli.Context, cfg *config.Config) (syncOptions, error) {
+	// Override the default sync visibility for this run; per-book rules still apply
+	if visibility := c.String("visibility"); visibility != "" {
+		if visibility != config.VisibilityPublic && visibility != config.VisibilityPrivate {
+			return syncOptions{}, fmt.Errorf("invalid --visibility %q: must be 'public' or 'private'", visibility)
+		}
+		cfg.Sync.Visibility = visibility
+	}
+
+	if bookMap := c.String("book-map"); bookMap != "" {
+		cfg.Books.MappingFile = bookMap
+	}
+
+	return syncOptions{
+		reportPath:     c.String("report-json"),
+		deadLetterPath: c.String("dead-letter"),
+		resolveBooks:   c.Bool("resolve-books"),
+		full:           c.Bool("full"),
+	}, nil
+}
+
+func syncDiffAction(c *cli.Context) error {
+	ctx := GetContext()
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	clippings, err := loadClippings(GetContext(), c.String("input"))
+	if err != nil {
+		return err
+	}
+
+	diff, err := diffWithServer(ctx, cfg, clippings)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		return outputJSON(os.Stdout, diff)
+	}
+	printDiff(os.Stdout, diff)
+	return nil
+}
+
+func syncApplyAction(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	opts, err := applySyncFlags(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	clippings, err := loadClippings(GetContext(), c.String("input"))
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("update-changed") {
+		if opts.full {
+			return fmt.Errorf("--update-changed cannot be combined with --full")
+		}
+		opts.confirmUpdates = func(changes []reconcile.Change) (bool, error) {
+			printChanges(os.Stderr, changes)
+			return confirm(c, fmt.Sprintf("Replace the server content of %d changed clippings?", len(changes)))
+		}
+	}
+
+	if len(clippings) == 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  No clippings found in input\n")
+		return nil
+	}
+
+	return syncToServer(GetContext(), cfg, clippings, "", opts)
+}
+
+// diffWithServer fetches the server library and compares it with clippings
+func diffWithServer(ctx context.Context, cfg *config.Config, clippings []models.ClippingItem) (reconcile.Diff, error) {
+	if err := requireToken(cfg); err != nil {
+		return reconcile.Diff{}, err
+	}
+
+	fmt.Fprintf(os.Stderr, "🔍 Comparing with ClippingKK service...\n")
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return reconcile.Diff{}, err
+	}
+	serverClippings, err := client.FetchClippings(ctx, ckk.FetchOptions{OnSkip: warnSkipped})
+	if err != nil {
+		return reconcile.Diff{}, err
+	}
+
+	return reconcile.Compare(clippings, serverClippings), nil
+}
+
+// requireToken fails with login instructions when cfg has no token
+func requireToken(cfg *config.Config) error {
+	if cfg.HasToken() {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "❌ No authentication token found\n")
+	fmt.Fprintf(os.Stderr, "Please login first: ck-cli login, or ck-cli login --token-stdin < token.txt\n")
+	return fmt.Errorf("not logged in")
+}
+
+// printDiff prints a human readable summary of the difference
+func printDiff(w io.Writer, diff reconcile.Diff) {
+	fmt.Fprintf(w, "%d unchanged, %d added locally, %d only on server, %d changed\n",
+		diff.Unchanged, len(diff.Added), len(diff.ServerOnly), len(diff.Changed))
+
+	printGroup := func(marker, heading string, items []models.ClippingItem) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s (%d)\n", heading, len(items))
+		for _, item := range items {
+			fmt.Fprintf(w, "%s %s %s  %s\n", marker, item.Title, item.PageAt, preview(item.Content))
+		}
+	}
+
+	printGroup("+", "Added locally", diff.Added)
+	printGroup("-", "Only on server", diff.ServerOnly)
+	printChanges(w, diff.Changed)
+}
+
+// printChanges lists clippings whose local content differs from the server
+func printChanges(w io.Writer, changes []reconcile.Change) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nChanged (%d)\n", len(changes))
+	for _, change := range changes {
+		fmt.Fprintf(w, "~ %s %s\n", change.Local.Title, change.Local.PageAt)
+		fmt.Fprintf(w, "    server: %s\n", preview(change.Server.Content))
+		fmt.Fprintf(w, "    local:  %s\n", preview(change.Local.Content))
+	}
+}
+
+// preview shortens content to a single line for terminal output
+func preview(content string) string {
+	const maxRunes = 60
+	content = strings.Join(strings.Fields(content), " ")
+	runes := []rune(content)
+	if len(runes) > maxRunes {
+		return string(runes[:maxRunes]) + "…"
+	}
+	return content
+}
+
+// syncToServer syncs clippings to ClippingKK service
+func syncToServer(ctx context.Context, cfg *config.Config, clippings []models.ClippingItem, endpoint string, opts syncOptions) error {
+	report, syncErr := pushClippings(ctx, cfg, clippings, endpoint, opts)
+	if report != nil && opts.reportPath != "" {
+		if err := writeReport(opts.reportPath, report); err != nil {
+			return err
+		}
+	}
+
+	if report != nil && opts.deadLetterPath != "" {
+		if rejected := report.Rejected(); len(rejected) > 0 {
+			if err := outputToFile(opts.deadLetterPath, rejected); err != nil {
+				return err
+			}
+		}
+	}
+
+	return syncErr
+}
+
+// pushClippings uploads clippings and returns the sync report, nil when
+// the server was already up to date
+func pushClippings(ctx context.Context, cfg *config.Config, clippings []models.ClippingItem, endpoint string, opts syncOptions) (*ckk.SyncReport, error) {
+	// Check if we have authentication
+	if err := requireToken(cfg); err != nil {
+		return nil, err
+	}
+
+	// An explicit URL output replaces the configured endpoint for this sync only
+	if endpoint != "" && endpoint != "http" {
+		override := *cfg
+		override.HTTP.Endpoint = endpoint
+		cfg = &override
+	}
+
+	// Only push what the server does not have yet
+	if !opts.full {
+		diff, err := diffWithServer(ctx, cfg, clippings)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "%d already on server, %d to upload, %d changed\n",
+			diff.Unchanged, len(diff.Added), len(diff.Changed))
+		if err := applyChanges(ctx, cfg, diff.Changed, opts); err != nil {
+			return nil, err
+		}
+		if len(diff.Added) == 0 {
+			fmt.Fprintf(os.Stderr, "✅ Already up to date\n")
+			return nil, nil
+		}
+		clippings = diff.Added
+	}
+
+	httpClient, err := newSyncClient(cfg, opts.resolveBooks)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "🚀 Starting sync to ClippingKK service...\n")
+
+	return httpClient.SyncToServer(ctx, clippings)
+}
+
+// applyChanges updates changed clippings when --update-changed asked for it
+// and the user confirmed, and only reports them otherwise
+func applyChanges(ctx context.Context, cfg *config.Config, changes []reconcile.Change, opts syncOptions) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	if opts.confirmUpdates == nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %d changed clippings left as they are on the server, see 'ck-cli sync diff' and 'ck-cli sync apply --update-changed'\n", len(changes))
+		return nil
+	}
+	ok, err := opts.confirmUpdates(changes)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Changed clippings left as they are\n")
+		return nil
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	if err := updateChanged(ctx, client, changes); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✅ Updated %d changed clippings\n", len(changes))
+	return nil
+}
+
+// updateChanged replaces the content of server clippings edited locally
+func updateChanged(ctx context.Context, client *ckk.Client, changes []reconcile.Change) error {
+	var errs []error
+	for _, change := range changes {
+		if change.Server.ID == 0 {
+			continue
+		}
+		content := change.Local.Content
+		if err := client.UpdateClipping(ctx, change.Server.ID, ckk.ClippingUpdate{Content: &content}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to update %d changed clippings: %w", len(errs), errors.Join(errs...))
+	}
+	return nil
+}
+
+// writeReport writes the sync report as JSON to a file or to stdout for "-"
+func writeReport(path string, report *ckk.SyncReport) error {
+	if path == "-" {
+		return outputJSON(os.Stdout, report)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer file.Close()
+
+	return outputJSON(file, report)
+}
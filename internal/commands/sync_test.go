@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clippingkk/cli/internal/config"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/reconcile"
+)
+
+func TestApplyChangesNeedsOptIn(t *testing.T) {
+	var updates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		updates = append(updates, string(body))
+		w.Write([]byte(`{"data":{"updateClipping":{"id":7}}}`))
+	}))
+	defer server.Close()
+
+	cfg := config.NewConfig()
+	cfg.HTTP.Endpoint = server.URL
+	cfg.UpdateToken("abc")
+	changes := []reconcile.Change{{
+		Local:  models.ClippingItem{Title: "Dune", Content: "edited"},
+		Server: models.ClippingItem{ID: 7, Title: "Dune", Content: "original"},
+	}}
+	ctx := context.Background()
+
+	// Without --update-changed the server is left alone
+	if err := applyChanges(ctx, cfg, changes, syncOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	declined := syncOptions{confirmUpdates: func([]reconcile.Change) (bool, error) { return false, nil }}
+	if err := applyChanges(ctx, cfg, changes, declined); err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("expected no update, got %v", updates)
+	}
+
+	confirmed := syncOptions{confirmUpdates: func([]reconcile.Change) (bool, error) { return true, nil }}
+	if err := applyChanges(ctx, cfg, changes, confirmed); err != nil {
+		t.Fatalf("applyChanges failed: %v", err)
+	}
+	if len(updates) != 1 || !strings.Contains(updates[0], "edited") {
+		t.Errorf("expected the local content to be sent, got %v", updates)
+	}
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clippingkk/cli/internal/device"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/watch"
+	"github.com/urfave/cli/v2"
+)
+
+// WatchCommand syncs new clippings whenever the clippings file grows
+var WatchCommand = &cli.Command{
+	Name:  "watch",
+	Usage: "Sync new clippings to ClippingKK whenever the clippings file changes",
+	Description: `Keep running and push clippings to ClippingKK as they are added.
+
+The file is polled for changes; once it stops changing for the debounce
+period, only the bytes appended since the last sync are parsed and pushed.
+The read offset is kept in the user cache directory so restarts do not
+upload everything again. A truncated or replaced file, or one watched for the
+first time, is read from the start and compared with the server.
+
+With --device (or --input device:) the most recently updated Kindle is
+watched while it is plugged in, and picked up again when it is re-mounted.
+Press Ctrl+C to stop.
+
+Examples:
+  # Watch a clippings file
+  ck-cli watch --input ~/Documents/"My Clippings.txt"
+
+  # Sync whenever a Kindle is plugged in
+  ck-cli watch --device`,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "input",
+			Aliases: []string{"i"},
+			Usage:   "Clippings file to watch, or device:[mount point] for a Kindle",
+		},
+		&cli.BoolFlag{
+			Name:    "device",
+			Aliases: []string{"d"},
+			Usage:   "Watch the clippings of a mounted Kindle",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "How often to check the clippings file",
+			Value: watch.DefaultInterval,
+		},
+		&cli.DurationFlag{
+			Name:  "debounce",
+			Usage: "How long the file must stay unchanged before syncing",
+			Value: watch.DefaultDebounce,
+		},
+	}, syncFlags...),
+	Action: watchAction,
+}
+
+func watchAction(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := requireToken(cfg); err != nil {
+		return err
+	}
+	opts, err := applySyncFlags(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	input := c.String("input")
+	if c.Bool("device") {
+		if input != "" {
+			return fmt.Errorf("--device cannot be combined with --input")
+		}
+		input = "device:"
+	}
+	if input == "" || input == "-" {
+		return fmt.Errorf("specify the clippings file with --input, or use --device")
+	}
+
+	locate, key, err := watchLocator(input)
+	if err != nil {
+		return err
+	}
+	statePath, err := watch.StatePath(key)
+	if err != nil {
+		return err
+	}
+
+	watcher := &watch.Watcher{
+		Locate: locate,
+		Handle: func(ctx context.Context, clippings []models.ClippingItem, fromStart bool) error {
+			// Appended clippings are new, only a file read from the start
+			// is compared with the server
+			pushOpts := opts
+			pushOpts.full = opts.full || !fromStart
+			return syncToServer(ctx, cfg, clippings, "", pushOpts)
+		},
+		StatePath: statePath,
+		Interval:  c.Duration("interval"),
+		Debounce:  c.Duration("debounce"),
+		Log:       os.Stderr,
+	}
+	return watcher.Run(GetContext())
+}
+
+// watchLocator returns how to find the watched clippings file and the key
+// its saved offset is stored under
+func watchLocator(input string) (func() (string, error), string, error) {
+	if mountPoint, ok := strings.CutPrefix(input, "device:"); ok {
+		mountPoint = strings.TrimPrefix(mountPoint, "//")
+		locate := func() (string, error) {
+			if mountPoint != "" {
+				kindle, ok := device.Stat(mountPoint)
+				if !ok {
+					return "", fmt.Errorf("no Kindle clippings found at %s", mountPoint)
+				}
+				return kindle.ClippingsPath, nil
+			}
+			kindle, err := device.Locate(device.DefaultRoots())
+			if err != nil {
+				return "", err
+			}
+			return kindle.ClippingsPath, nil
+		}
+		// A Kindle may be mounted at a different path each time
+		return locate, "device:" + mountPoint, nil
+	}
+
+	path, err := filepath.Abs(strings.TrimPrefix(input, "file:"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve %s: %w", input, err)
+	}
+	locate := func() (string, error) {
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+	return locate, path, nil
+}
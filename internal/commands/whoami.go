@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/clippingkk/cli/pkg/ckk"
+	"github.com/urfave/cli/v2"
+)
+
+// WhoamiCommand shows the account of the active profile
+var WhoamiCommand = &cli.Command{
+	Name:  "whoami",
+	Usage: "Show the ClippingKK account of the active profile's token",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the account as JSON",
+		},
+	},
+	Action: whoamiAction,
+}
+
+// whoamiResult describes the active profile and its account
+type whoamiResult struct {
+	Profile  string       `json:"profile"`
+	Endpoint string       `json:"endpoint"`
+	Account  *ckk.Account `json:"account"`
+	Token    tokenDetails `json:"token"`
+}
+
+func whoamiAction(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if !cfg.HasToken() {
+		return fmt.Errorf("not logged in to profile %q, run 'ck-cli login'", cfg.ProfileName())
+	}
+
+	account, details, err := verifyToken(GetContext(), cfg)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		return outputJSON(os.Stdout, whoamiResult{
+			Profile:  cfg.ProfileName(),
+			Endpoint: cfg.HTTP.Endpoint,
+			Account:  account,
+			Token:    details,
+		})
+	}
+
+	fmt.Printf("%s (id %d)\n", formatAccount(account), account.ID)
+	fmt.Printf("   Profile: %s\n   Server:  %s\n", cfg.ProfileName(), cfg.HTTP.Endpoint)
+	printTokenInfo(os.Stdout, details)
+	return nil
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/clippingkk/cli/pkg/ckk"
+)
+
+// Authentication schemes of a profile, see AuthConfig.Scheme
+const (
+	// AuthToken sends the API token as "Authorization: X-CLI <token>"
+	AuthToken = "x-cli"
+	// AuthBearer sends the token as "Authorization: Bearer <token>"
+	AuthBearer = "bearer"
+	// AuthBasic sends the username and the stored password
+	AuthBasic = "basic"
+	// AuthOAuth2 logs in with the OAuth2 device flow and refreshes the token
+	AuthOAuth2 = "oauth2"
+	// AuthMTLS authenticates with a TLS client certificate only
+	AuthMTLS = "mtls"
+)
+
+// authSchemes lists the accepted values of AuthConfig.Scheme
+var authSchemes = []string{AuthToken, AuthBearer, AuthBasic, AuthOAuth2, AuthMTLS}
+
+// AuthConfig holds how a profile authenticates. The secret of the scheme,
+// the token, password or OAuth2 token, is kept in the credential store.
+type AuthConfig struct {
+	// Scheme is one of the Auth* constants, AuthToken when empty
+	Scheme string `toml:"auth,omitempty"`
+	// Username is the user of basic authentication
+	Username string        `toml:"username,omitempty"`
+	OAuth2   *OAuth2Config `toml:"oauth2,omitempty"`
+	// MTLS adds a client certificate to any scheme
+	MTLS *MTLSConfig `toml:"mtls,omitempty"`
+}
+
+// OAuth2Config describes the authorization server of an oauth2 profile
+type OAuth2Config struct {
+	ClientID      string   `toml:"client_id"`
+	DeviceAuthURL string   `toml:"device_auth_url"`
+	TokenURL      string   `toml:"token_url"`
+	Scopes        []string `toml:"scopes,omitempty"`
+}
+
+// MTLSConfig locates the PEM client certificate and key of a profile
+type MTLSConfig struct {
+	CertFile string `toml:"cert_file"`
+	KeyFile  string `toml:"key_file"`
+}
+
+// EffectiveScheme returns the scheme, AuthToken when unset
+func (a AuthConfig) EffectiveScheme() string {
+	if a.Scheme == "" {
+		return AuthToken
+	}
+	return a.Scheme
+}
+
+// validate checks the scheme and the settings it needs
+func (a AuthConfig) validate(prefix string) []string {
+	var problems []string
+	switch a.EffectiveScheme() {
+	case AuthToken, AuthBearer:
+	case AuthBasic:
+		if a.Username == "" {
+			problems = append(problems, fmt.Sprintf("%s.username: required by basic authentication", prefix))
+		}
+	case AuthOAuth2:
+		if a.OAuth2 == nil {
+			problems = append(problems, fmt.Sprintf("%s.oauth2: required by oauth2 authentication", prefix))
+		}
+	case AuthMTLS:
+		if a.MTLS == nil {
+			problems = append(problems, fmt.Sprintf("%s.mtls: required by mtls authentication", prefix))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("%s.auth: invalid scheme %q, expected one of %s", prefix, a.Scheme, strings.Join(authSchemes, ", ")))
+	}
+
+	if o := a.OAuth2; o != nil {
+		if o.ClientID == "" {
+			problems = append(problems, fmt.Sprintf("%s.oauth2.client_id: required", prefix))
+		}
+		for key, value := range map[string]string{"device_auth_url": o.DeviceAuthURL, "token_url": o.TokenURL} {
+			if err := ValidateEndpoint(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.oauth2.%s: %v", prefix, key, err))
+			}
+		}
+	}
+	if m := a.MTLS; m != nil && (m.CertFile == "" || m.KeyFile == "") {
+		problems = append(problems, fmt.Sprintf("%s.mtls: cert_file and key_file are required", prefix))
+	}
+	return problems
+}
+
+// Client returns the settings for the ckk OAuth2 client
+func (o OAuth2Config) Client() ckk.OAuth2Config {
+	return ckk.OAuth2Config{ClientID: o.ClientID, DeviceAuthURL: o.DeviceAuthURL, TokenURL: o.TokenURL, Scopes: o.Scopes}
+}
+
+// ParseOAuth2Token reads the secret of an oauth2 profile: the JSON written
+// by EncodeOAuth2Token, or a bare access token given with --token or
+// $CK_CLI_TOKEN
+func ParseOAuth2Token(secret string) ckk.OAuth2Token {
+	var token ckk.OAuth2Token
+	if strings.HasPrefix(secret, "{") && json.Unmarshal([]byte(secret), &token) == nil {
+		return token
+	}
+	return ckk.OAuth2Token{AccessToken: secret}
+}
+
+// EncodeOAuth2Token turns a token into the secret of an oauth2 profile
+func EncodeOAuth2Token(token ckk.OAuth2Token) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token: %w", err)
+	}
+	return string(data), nil
+}
+
+// AccessToken returns the token sent to the server: the API token, or the
+// access token of an oauth2 profile
+func (c *Config) AccessToken() string {
+	if c.HTTP.Auth.EffectiveScheme() == AuthOAuth2 {
+		return ParseOAuth2Token(c.HTTP.token()).AccessToken
+	}
+	return c.HTTP.token()
+}
+
+// Auth returns the provider authenticating the requests of the active
+// profile, nil when the profile sends no credentials. OAuth2 tokens
+// renewed while the provider is used are saved to the credential store.
+func (c *Config) Auth() (ckk.Auth, error) {
+	var auths []ckk.Auth
+	if m := c.HTTP.Auth.MTLS; m != nil {
+		certFile, err := expandHome(m.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		keyFile, err := expandHome(m.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		auth, err := ckk.LoadClientCertAuth(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", c.profile, err)
+		}
+		auths = append(auths, auth)
+	}
+
+	if secret := c.HTTP.token(); secret != "" {
+		switch c.HTTP.Auth.EffectiveScheme() {
+		case AuthToken:
+			auths = append(auths, ckk.TokenAuth(secret))
+		case AuthBearer:
+			auths = append(auths, ckk.BearerAuth(secret))
+		case AuthBasic:
+			auths = append(auths, ckk.BasicAuth(c.HTTP.Auth.Username, secret))
+		case AuthOAuth2:
+			if c.HTTP.Auth.OAuth2 == nil {
+				return nil, fmt.Errorf("profile %q has no [oauth2] settings", c.profile)
+			}
+			auths = append(auths, ckk.NewOAuth2Auth(c.HTTP.Auth.OAuth2.Client(), ParseOAuth2Token(secret), c.saveOAuth2Token))
+		}
+	}
+
+	switch len(auths) {
+	case 0:
+		return nil, nil
+	case 1:
+		return auths[0], nil
+	}
+	return ckk.MultiAuth(auths...), nil
+}
+
+// saveOAuth2Token keeps a renewed token of the active profile. Only tokens
+// read from the credential store are written back; a token given with
+// --token or $CK_CLI_TOKEN is used for this run only.
+func (c *Config) saveOAuth2Token(token ckk.OAuth2Token) error {
+	secret, err := EncodeOAuth2Token(token)
+	if err != nil {
+		return err
+	}
+	c.HTTP.setToken(secret)
+	if _, overridden := c.origins["token"]; overridden || c.store == nil {
+		return nil
+	}
+	if profile, ok := c.Profiles[c.profile]; ok && profile.Token != "" {
+		profile.Token = secret
+	}
+	return c.store.Store(c.profile, c.HTTP.Endpoint, secret)
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/pkg/ckk"
+)
+
+func TestAuthSchemes(t *testing.T) {
+	cfg := NewConfig()
+	profiles := map[string]Profile{
+		"token":  {Endpoint: "https://a.example/graphql", Token: "abc"},
+		"bearer": {Endpoint: "https://b.example/graphql", AuthConfig: AuthConfig{Scheme: AuthBearer}, Token: "abc"},
+		"basic":  {Endpoint: "https://c.example/graphql", AuthConfig: AuthConfig{Scheme: AuthBasic, Username: "reader"}, Token: "pass word"},
+		"oauth2": {Endpoint: "https://d.example/graphql", AuthConfig: AuthConfig{Scheme: AuthOAuth2, OAuth2: &OAuth2Config{ClientID: "ck-cli", DeviceAuthURL: "https://sso.example/device", TokenURL: "https://sso.example/token"}}, Token: `{"access_token":"access-1","refresh_token":"refresh-1"}`},
+	}
+	expected := map[string]string{
+		"token":  "X-CLI abc",
+		"bearer": "Bearer abc",
+		"basic":  "Basic " + base64.StdEncoding.EncodeToString([]byte("reader:pass word")),
+		"oauth2": "Bearer access-1",
+	}
+	for name, profile := range profiles {
+		if err := cfg.AddProfile(name, profile, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	for name, want := range expected {
+		if err := cfg.UseProfile(name); err != nil {
+			t.Fatal(err)
+		}
+		if !cfg.HasToken() {
+			t.Errorf("%s: expected a token", name)
+		}
+		auth, err := cfg.Auth()
+		if err != nil {
+			t.Fatalf("%s: Auth failed: %v", name, err)
+		}
+		req, _ := http.NewRequest("POST", cfg.HTTP.Endpoint, nil)
+		if err := auth.Apply(context.Background(), req); err != nil {
+			t.Fatalf("%s: Apply failed: %v", name, err)
+		}
+		if got := req.Header.Get("Authorization"); got != want {
+			t.Errorf("%s: Authorization = %q, expected %q", name, got, want)
+		}
+		// Only the API token is sent as a plain header
+		if _, ok := cfg.HTTP.Headers["Authorization"]; ok != (name == "token") {
+			t.Errorf("%s: unexpected headers %v", name, cfg.HTTP.Headers)
+		}
+	}
+	if err := cfg.UseProfile("oauth2"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.AccessToken(); got != "access-1" {
+		t.Errorf("AccessToken = %q", got)
+	}
+}
+
+func TestAuthValidation(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Profiles["default"].AuthConfig = AuthConfig{Scheme: "kerberos"}
+	cfg.Profiles["basic"] = &Profile{AuthConfig: AuthConfig{Scheme: AuthBasic}}
+	cfg.Profiles["oauth2"] = &Profile{AuthConfig: AuthConfig{Scheme: AuthOAuth2}}
+	cfg.Profiles["mtls"] = &Profile{AuthConfig: AuthConfig{Scheme: AuthMTLS, MTLS: &MTLSConfig{CertFile: "client.pem"}}}
+	cfg.Profiles["sso"] = &Profile{AuthConfig: AuthConfig{OAuth2: &OAuth2Config{TokenURL: "sso.example/token"}}}
+
+	err := cfg.Validate()
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	message := err.Error()
+	for _, want := range []string{
+		`profiles.default.auth: invalid scheme "kerberos"`,
+		"profiles.basic.username: required",
+		"profiles.oauth2.oauth2: required",
+		"profiles.mtls.mtls: cert_file and key_file are required",
+		"profiles.sso.oauth2.client_id: required",
+		"profiles.sso.oauth2.token_url: invalid URL",
+		"profiles.sso.oauth2.device_auth_url: invalid URL",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("expected %q in:\n%s", want, message)
+		}
+	}
+}
+
+func TestProjectCannotConfigureOAuth2(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "home", ".ck-cli.toml")
+	writeFile(t, userPath, `
+[profiles.default]
+endpoint = "https://user.example/graphql"
+auth = "oauth2"
+[profiles.default.oauth2]
+client_id = "ck-cli"
+device_auth_url = "https://sso.example/device"
+token_url = "https://sso.example/token"
+`)
+	writeFile(t, filepath.Join(dir, "work", ConfigFileName), `
+[profiles.default.oauth2]
+client_id = "evil"
+device_auth_url = "https://evil.example/device"
+token_url = "https://evil.example/token"
+[profiles.default.mtls]
+cert_file = "/tmp/cert.pem"
+key_file = "/tmp/key.pem"
+`)
+
+	cfg, err := Resolve(ResolveOptions{
+		UserPath:   userPath,
+		SystemPath: filepath.Join(dir, "missing.toml"),
+		WorkDir:    filepath.Join(dir, "work"),
+		Getenv:     func(string) string { return "" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Auth.OAuth2.TokenURL != "https://sso.example/token" || cfg.HTTP.Auth.MTLS != nil {
+		t.Errorf("expected the project settings to be ignored, got %+v", cfg.HTTP.Auth)
+	}
+}
+
+func TestSaveRefreshedOAuth2Token(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ck-cli.toml")
+	writeFile(t, path, `
+[profiles.default]
+endpoint = "https://user.example/graphql"
+auth = "oauth2"
+[profiles.default.oauth2]
+client_id = "ck-cli"
+device_auth_url = "https://sso.example/device"
+token_url = "https://sso.example/token"
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HasToken() {
+		t.Error("expected no token before login")
+	}
+
+	token := ckk.OAuth2Token{AccessToken: "access-2", RefreshToken: "refresh-1", Expiry: time.Now().Add(time.Hour).Round(time.Second)}
+	if err := cfg.saveOAuth2Token(token); err != nil {
+		t.Fatalf("saveOAuth2Token failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.UseStoredToken(); err != nil {
+		t.Fatal(err)
+	}
+	got := ParseOAuth2Token(loaded.Token())
+	if got.AccessToken != "access-2" || got.RefreshToken != "refresh-1" || !got.Expiry.Equal(token.Expiry) {
+		t.Errorf("unexpected stored token %+v", got)
+	}
+}
+
+func TestSetOAuth2Keys(t *testing.T) {
+	cfg := NewConfig()
+	for key, value := range map[string]string{
+		"profiles.default.oauth2.client_id":       "ck-cli",
+		"profiles.default.oauth2.device_auth_url": "https://sso.example/device",
+		"profiles.default.oauth2.token_url":       "https://sso.example/token",
+		"profiles.default.auth":                   AuthOAuth2,
+	} {
+		if err := cfg.Set(key, value); err != nil {
+			t.Fatalf("Set(%q) failed: %v", key, err)
+		}
+	}
+	if cfg.HTTP.Auth.EffectiveScheme() != AuthOAuth2 || cfg.HTTP.Auth.OAuth2.ClientID != "ck-cli" {
+		t.Errorf("unexpected auth settings %+v", cfg.HTTP.Auth)
+	}
+	if err := cfg.Set("profiles.default.auth", "kerberos"); err == nil {
+		t.Error("expected an unknown scheme to be rejected")
+	}
+
+	// Reading or emptying a key of a missing table leaves no table behind
+	if _, err := cfg.Get("profiles.default.mtls.cert_file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Set("profiles.default.mtls.cert_file", ""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profiles["default"].MTLS != nil {
+		t.Error("expected no [mtls] table")
+	}
+}
@@ -1,132 +1,538 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/clippingkk/cli/pkg/ckk"
 	"github.com/pelletier/go-toml/v2"
 )
 
 const (
 	// DefaultEndpoint is the default ClippingKK GraphQL endpoint
-	DefaultEndpoint = "https://clippingkk-api.annatarhe.com/api/v2/graphql"
+	DefaultEndpoint = ckk.DefaultEndpoint
 	// ConfigFileName is the default configuration file name
 	ConfigFileName = ".ck-cli.toml"
+	// BookMappingFileName is the default file pinning book titles to book IDs
+	BookMappingFileName = ".ck-cli.books.toml"
+
+	// VisibilityPublic makes synced clippings visible to everyone
+	VisibilityPublic = "public"
+	// VisibilityPrivate keeps synced clippings visible only to their owner
+	VisibilityPrivate = "private"
 )
 
 // Config represents the configuration structure
 type Config struct {
-	HTTP HTTPConfig `toml:"http"`
+	// DefaultProfile is used unless another profile is selected
+	DefaultProfile string              `toml:"default_profile"`
+	Profiles       map[string]*Profile `toml:"profiles"`
+	// HTTP holds the effective settings of the active profile; it is
+	// derived by UseProfile and never written to the file
+	HTTP        HTTPConfig        `toml:"-"`
+	Sync        SyncConfig        `toml:"sync,omitempty"`
+	Books       BooksConfig       `toml:"books,omitempty"`
+	Library     LibraryConfig     `toml:"library,omitempty"`
+	Credentials CredentialsConfig `toml:"credentials,omitempty"`
+	// Transport holds the [http.tls] and [http.proxy] sections; it is
+	// copied into HTTP by UseProfile
+	Transport TransportConfig `toml:"-"`
+
+	// profile is the name of the active profile
+	profile string
+	// store keeps the tokens of the user config file
+	store CredentialStore
+	// erased maps removed profiles to their endpoint until the next Save
+	// erases their tokens
+	erased map[string]string
+	// origins records the layer each setting came from, see Resolve
+	origins map[string]Origin
+}
+
+// LibraryConfig locates the local library read by commands without --input
+type LibraryConfig struct {
+	// Input is any clippings input, e.g. "dir:~/kindle-backups" or "device:"
+	Input string `toml:"input,omitempty"`
+}
+
+// BooksConfig represents settings for resolving book IDs before upload
+type BooksConfig struct {
+	// MappingFile pins titles to book IDs, defaults to ~/.ck-cli.books.toml
+	MappingFile string `toml:"mapping_file,omitempty"`
+	// CacheFile stores book IDs found by searching the service, per endpoint
+	CacheFile string `toml:"cache_file,omitempty"`
+}
+
+// ResolvedMappingFile returns the book mapping file path with defaults applied
+func (b BooksConfig) ResolvedMappingFile() (string, error) {
+	if b.MappingFile != "" {
+		return expandHome(b.MappingFile)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, BookMappingFileName), nil
+}
+
+// ResolvedCacheFile returns the book cache file path with defaults applied
+func (b BooksConfig) ResolvedCacheFile() (string, error) {
+	if b.CacheFile != "" {
+		return expandHome(b.CacheFile)
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "ck-cli", "books.json"), nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) (string, error) {
+	if len(path) == 0 || path[0] != '~' {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, path[1:]), nil
 }
 
 // HTTPConfig represents HTTP configuration
 type HTTPConfig struct {
-	Endpoint string            `toml:"endpoint"`
-	Headers  map[string]string `toml:"headers"`
+	Endpoint string            `toml:"endpoint,omitempty"`
+	Headers  map[string]string `toml:"headers,omitempty"`
+	TransportConfig
+	// Auth holds the authentication settings of the active profile
+	Auth AuthConfig `toml:"-"`
+
+	// secret is the token of schemes not sending it as X-CLI header
+	secret string
+}
+
+// SyncConfig represents settings applied when uploading clippings
+type SyncConfig struct {
+	// Visibility is the default visibility, "public" (default) or "private"
+	Visibility string `toml:"visibility,omitempty"`
+	// Rules override the default visibility for matching book titles
+	Rules []VisibilityRule `toml:"rules,omitempty"`
+}
+
+// VisibilityRule sets the visibility of every book whose title matches Title
+type VisibilityRule struct {
+	// Title is a regular expression matched against the book title
+	Title      string `toml:"title"`
+	Visibility string `toml:"visibility"`
+}
+
+// VisibilityPolicy decides whether a clipping is published as public
+type VisibilityPolicy struct {
+	visible bool
+	rules   []visibilityMatcher
+}
+
+type visibilityMatcher struct {
+	pattern *regexp.Regexp
+	visible bool
 }
 
-// NewConfig creates a new configuration with default values
+// Policy compiles the sync visibility settings. The first rule matching a
+// title wins; titles matching no rule use the default visibility.
+func (s SyncConfig) Policy() (*VisibilityPolicy, error) {
+	visible, err := parseVisibility(s.Visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &VisibilityPolicy{visible: visible}
+	for _, rule := range s.Rules {
+		pattern, err := regexp.Compile(rule.Title)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title pattern %q in visibility rule: %w", rule.Title, err)
+		}
+		ruleVisible, err := parseVisibility(rule.Visibility)
+		if err != nil {
+			return nil, fmt.Errorf("visibility rule %q: %w", rule.Title, err)
+		}
+		policy.rules = append(policy.rules, visibilityMatcher{pattern: pattern, visible: ruleVisible})
+	}
+
+	return policy, nil
+}
+
+// Visible reports whether clippings of the given book should be public
+func (p *VisibilityPolicy) Visible(title string) bool {
+	for _, rule := range p.rules {
+		if rule.pattern.MatchString(title) {
+			return rule.visible
+		}
+	}
+	return p.visible
+}
+
+// parseVisibility converts a visibility name to the mutation's visible flag
+func parseVisibility(visibility string) (bool, error) {
+	switch visibility {
+	case "", VisibilityPublic:
+		return true, nil
+	case VisibilityPrivate:
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid visibility %q: must be %q or %q", visibility, VisibilityPublic, VisibilityPrivate)
+	}
+}
+
+// NewConfig creates a new configuration with a single default profile
 func NewConfig() *Config {
-	return &Config{
-		HTTP: HTTPConfig{
-			Endpoint: DefaultEndpoint,
-			Headers:  make(map[string]string),
+	config := &Config{
+		DefaultProfile: DefaultProfileName,
+		Profiles: map[string]*Profile{
+			DefaultProfileName: {Endpoint: DefaultEndpoint},
 		},
 	}
+	config.UseProfile("")
+	return config
 }
 
-// UpdateToken adds or updates the authorization token
+// UpdateToken sets the token of the active profile: the API token, the
+// password of basic authentication or the OAuth2 token encoded by
+// EncodeOAuth2Token. Save moves the token to the credential store.
 func (c *Config) UpdateToken(token string) {
-	if c.HTTP.Headers == nil {
-		c.HTTP.Headers = make(map[string]string)
+	if profile, ok := c.Profiles[c.profile]; ok {
+		profile.Token = token
 	}
-	c.HTTP.Headers["Authorization"] = fmt.Sprintf("X-CLI %s", token)
+	c.HTTP.setToken(token)
+}
+
+// UseStoredToken adds the token kept in the credential store to the active
+// profile, unless the config files already give one. A token is only used
+// for the endpoint it was stored for.
+func (c *Config) UseStoredToken() error {
+	profile, ok := c.Profiles[c.profile]
+	if !ok || profile.Token != "" || c.store == nil {
+		return nil
+	}
+
+	token, err := c.store.Get(c.profile, c.HTTP.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to read token of profile %q: %w", c.profile, err)
+	}
+	if token == "" {
+		return nil
+	}
+	c.HTTP.setToken(token)
+	if c.origins == nil {
+		c.origins = make(map[string]Origin)
+	}
+	c.origins["profiles."+c.profile+".token"] = Origin{Source: SourceCredentials, Detail: c.store.String()}
+	return nil
+}
+
+// ProfileToken returns the token of the named profile from the config
+// files, else from the credential store
+func (c *Config) ProfileToken(name string) (string, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return "", fmt.Errorf("unknown profile %q", name)
+	}
+	if profile.Token != "" || c.store == nil {
+		return profile.Token, nil
+	}
+	return c.store.Get(name, profile.endpointOrDefault())
+}
+
+// ForgetToken removes the token of the active profile; Save erases it from
+// the credential store
+func (c *Config) ForgetToken() {
+	profile, ok := c.Profiles[c.profile]
+	if !ok {
+		return
+	}
+	profile.Token = ""
+	if c.erased == nil {
+		c.erased = make(map[string]string)
+	}
+	c.erased[c.profile] = profile.endpointOrDefault()
+	c.activate(profile)
+}
+
+// Token returns the API token the active profile sends, if any
+func (c *Config) Token() string {
+	return c.HTTP.token()
 }
 
-// HasToken checks if the configuration has an authorization token
+// HasToken checks if the active profile sends credentials
 func (c *Config) HasToken() bool {
-	return c.HTTP.Headers != nil && c.HTTP.Headers["Authorization"] != ""
+	return c.HTTP.HasAuthorization()
 }
 
-// Save writes the configuration to the specified file path
+// HasAuthorization reports whether the settings authenticate requests: an
+// Authorization header, the secret of the scheme or a client certificate
+func (h HTTPConfig) HasAuthorization() bool {
+	if h.secret != "" || h.Auth.MTLS != nil && h.Auth.EffectiveScheme() == AuthMTLS {
+		return true
+	}
+	for name, value := range h.Headers {
+		if strings.EqualFold(name, "Authorization") && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the configuration to the specified file path. Tokens are
+// never written to the config file: they go to the credential store
+// configured in [credentials], by default a 0600 file next to path.
 func (c *Config) Save(path string) error {
-	data, err := toml.Marshal(c)
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	store, err := c.Credentials.Store(path)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return err
 	}
 
-	// Ensure the directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	for name, endpoint := range c.erased {
+		if err := store.Erase(name, endpoint); err != nil {
+			return fmt.Errorf("failed to erase token of profile %q: %w", name, err)
+		}
 	}
+	c.erased = nil
 
-	err = os.WriteFile(path, data, 0644)
+	file := *c
+	file.Profiles = make(map[string]*Profile, len(c.Profiles))
+	for _, name := range c.ProfileNames() {
+		profile := *c.Profiles[name]
+		if profile.Token != "" {
+			if err := store.Store(name, profile.endpointOrDefault(), profile.Token); err != nil {
+				return fmt.Errorf("failed to store token of profile %q: %w", name, err)
+			}
+			profile.Token = ""
+		}
+		file.Profiles[name] = &profile
+	}
+
+	document := fileConfig{Config: file}
+	if !c.Transport.isZero() {
+		document.HTTP = &HTTPConfig{TransportConfig: c.Transport}
+	}
+	data, err := toml.Marshal(&document)
 	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// Headers may still hold credentials, so the file stays private too
+	if err := writeFileAtomic(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	c.store = store
 	return nil
 }
 
-// Load reads the configuration from the specified file path
+// Load reads the configuration from the specified file path for editing
+// and activates the default profile. Files written before profiles existed,
+// with a single [http] block, are migrated to a "default" profile, and tokens
+// written in the file are moved to the credential store; the original,
+// stripped of its tokens, is kept next to the file with a ".bak" suffix.
+// Stored tokens are only read by UseStoredToken.
 func Load(path string) (*Config, error) {
+	path, config, data, migrated, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if migrated || config.hasInlineTokens() {
+		backup, err := redactTokens(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up config before migration: %w", err)
+		}
+		if err := os.WriteFile(path+".bak", backup, 0600); err != nil {
+			return nil, fmt.Errorf("failed to back up config before migration: %w", err)
+		}
+		if err := config.Save(path); err != nil {
+			return nil, fmt.Errorf("failed to migrate config: %w", err)
+		}
+	}
+
+	if err := config.useDefaultProfile(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Read reads the configuration like Load without changing the file: a
+// legacy [http] block and tokens written in the file are only migrated in
+// memory. Use it to inspect or validate a config.
+func Read(path string) (*Config, error) {
+	_, config, _, _, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.useDefaultProfile(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// read parses the config file at path, the default location when empty.
+// It returns the expanded path, the file data and whether a legacy [http]
+// block was turned into a profile.
+func read(path string) (string, *Config, []byte, bool, error) {
 	// If path is empty, use default location
 	if path == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
+			return "", nil, nil, false, fmt.Errorf("failed to get home directory: %w", err)
 		}
 		path = filepath.Join(homeDir, ConfigFileName)
 	}
 
 	// Handle ~ prefix
-	if len(path) > 0 && path[0] == '~' {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		path = filepath.Join(homeDir, path[1:])
+	path, err := expandHome(path)
+	if err != nil {
+		return "", nil, nil, false, err
 	}
 
-	// If file doesn't exist, create default config
+	// A missing file reads as the default config; it is only created by Save
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		config := NewConfig()
-		if err := config.Save(path); err != nil {
-			return nil, fmt.Errorf("failed to create default config: %w", err)
+		if config.store, err = config.Credentials.Store(path); err != nil {
+			return "", nil, nil, false, err
 		}
-		return config, nil
+		return path, config, nil, false, nil
 	}
 
 	// Read existing config
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return "", nil, nil, false, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config, migrated, err := decode(path, data)
+	if err != nil {
+		return "", nil, nil, false, err
+	}
+	if config.store, err = config.Credentials.Store(path); err != nil {
+		return "", nil, nil, false, err
+	}
+	return path, config, data, migrated, nil
+}
+
+// useDefaultProfile makes sure a profile exists and activates the default one
+func (c *Config) useDefaultProfile() error {
+	c.ensureProfile()
+	if err := c.UseProfile(""); err != nil {
+		return fmt.Errorf("invalid default_profile: %w", err)
+	}
+	return nil
+}
+
+// hasInlineTokens reports whether a profile token was read from the file
+func (c *Config) hasInlineTokens() bool {
+	for _, profile := range c.Profiles {
+		if profile.Token != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// redactTokens returns the config file data without the profile tokens and
+// X-CLI Authorization headers written in it, for the backup kept before a
+// migration
+func redactTokens(data []byte) ([]byte, error) {
+	file, err := decodeStrict(data)
+	if err != nil {
+		return nil, err
 	}
+	for _, profile := range file.Profiles {
+		profile.Token = ""
+	}
+	if file.HTTP != nil {
+		for name, value := range file.HTTP.Headers {
+			if strings.EqualFold(name, "Authorization") && strings.HasPrefix(value, tokenPrefix) {
+				delete(file.HTTP.Headers, name)
+			}
+		}
+	}
+	return toml.Marshal(file)
+}
 
-	var config Config
-	err = toml.Unmarshal(data, &config)
+// decode parses and validates a config file, turning the endpoint and
+// headers of a legacy [http] block into the default profile. migrated reports whether that happened.
+// Settings missing from the file are left empty so layers can be merged.
+func decode(path string, data []byte) (config *Config, migrated bool, err error) {
+	file, err := decodeStrict(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, false, withPath(err, path)
+	}
+	config = &file.Config
+	if file.HTTP != nil {
+		config.Transport = file.HTTP.TransportConfig
 	}
 
-	// Ensure defaults
-	if config.HTTP.Endpoint == "" {
-		config.HTTP.Endpoint = DefaultEndpoint
+	if len(config.Profiles) == 0 && file.HTTP != nil && (file.HTTP.Endpoint != "" || len(file.HTTP.Headers) > 0) {
+		config.Profiles = map[string]*Profile{DefaultProfileName: migrateHTTP(*file.HTTP)}
+		if config.DefaultProfile == "" {
+			config.DefaultProfile = DefaultProfileName
+		}
+		migrated = true
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, false, withPath(err, path)
 	}
-	if config.HTTP.Headers == nil {
-		config.HTTP.Headers = make(map[string]string)
+	return config, migrated, nil
+}
+
+// withPath names the file in a ValidationError
+func withPath(err error, path string) error {
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		invalid.Path = path
 	}
+	return err
+}
 
-	return &config, nil
+// ensureProfile adds the default profile to a config without profiles and
+// makes a single profile the default even when not named so
+func (c *Config) ensureProfile() {
+	if len(c.Profiles) == 0 {
+		c.Profiles = map[string]*Profile{DefaultProfileName: {Endpoint: DefaultEndpoint}}
+	}
+	if _, ok := c.Profiles[c.DefaultProfile]; ok {
+		return
+	}
+	if c.DefaultProfile == "" {
+		if _, ok := c.Profiles[DefaultProfileName]; ok {
+			c.DefaultProfile = DefaultProfileName
+			return
+		}
+	}
+	if len(c.Profiles) == 1 {
+		c.DefaultProfile = c.ProfileNames()[0]
+	}
 }
 
-// GetConfigPath returns the configuration file path
+// GetConfigPath returns the user configuration file path: customPath,
+// else $CK_CLI_CONFIG, else ~/.ck-cli.toml
 func GetConfigPath(customPath string) (string, error) {
 	if customPath != "" {
 		return customPath, nil
 	}
+	if envPath := os.Getenv(EnvConfig); envPath != "" {
+		return envPath, nil
+	}
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -134,4 +540,4 @@ func GetConfigPath(customPath string) (string, error) {
 	}
 
 	return filepath.Join(homeDir, ConfigFileName), nil
-}
\ No newline at end of file
+}
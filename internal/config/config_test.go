@@ -0,0 +1,290 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVisibilityPolicy(t *testing.T) {
+	sync := SyncConfig{
+		Visibility: VisibilityPublic,
+		Rules: []VisibilityRule{
+			{Title: "(?i)^work", Visibility: VisibilityPrivate},
+			{Title: ".*", Visibility: VisibilityPublic},
+		},
+	}
+
+	policy, err := sync.Policy()
+	if err != nil {
+		t.Fatalf("Policy failed: %v", err)
+	}
+
+	tests := []struct {
+		title    string
+		expected bool
+	}{
+		{"Work Handbook", false},
+		{"work notes", false},
+		{"The Great Gatsby", true},
+	}
+	for _, test := range tests {
+		if got := policy.Visible(test.title); got != test.expected {
+			t.Errorf("Visible(%q) = %v, expected %v", test.title, got, test.expected)
+		}
+	}
+
+	private, err := SyncConfig{Visibility: VisibilityPrivate}.Policy()
+	if err != nil {
+		t.Fatalf("Policy failed: %v", err)
+	}
+	if private.Visible("Anything") {
+		t.Error("Expected private default visibility")
+	}
+}
+
+func TestVisibilityPolicyInvalid(t *testing.T) {
+	invalid := []SyncConfig{
+		{Visibility: "hidden"},
+		{Rules: []VisibilityRule{{Title: "(", Visibility: VisibilityPrivate}}},
+		{Rules: []VisibilityRule{{Title: "x", Visibility: "secret"}}},
+	}
+	for _, cfg := range invalid {
+		if _, err := cfg.Policy(); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestLoadMigratesHTTPBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	legacy := `[http]
+endpoint = "https://staging.example.com/graphql"
+
+[http.headers]
+Authorization = "X-CLI secret"
+X-Team = "books"
+
+[sync]
+visibility = "private"
+`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ProfileName() != DefaultProfileName {
+		t.Errorf("expected active profile %q, got %q", DefaultProfileName, cfg.ProfileName())
+	}
+	profile, ok := cfg.Profile(DefaultProfileName)
+	if !ok || profile.Token != "secret" || profile.Endpoint != "https://staging.example.com/graphql" || profile.Headers["X-Team"] != "books" {
+		t.Fatalf("unexpected migrated profile %+v", profile)
+	}
+	if cfg.HTTP.Headers["Authorization"] != "X-CLI secret" || cfg.HTTP.Endpoint != profile.Endpoint {
+		t.Errorf("unexpected effective HTTP settings %+v", cfg.HTTP)
+	}
+	if cfg.Sync.Visibility != VisibilityPrivate {
+		t.Errorf("expected other settings to be kept, got %+v", cfg.Sync)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Errorf("expected a backup of the original file: %v", err)
+	}
+	if !strings.Contains(string(backup), "[http]") || !strings.Contains(string(backup), "X-Team") {
+		t.Errorf("expected the backup to keep the original layout, got:\n%s", backup)
+	}
+	if strings.Contains(string(backup), "secret") {
+		t.Errorf("expected the token to be left out of the backup, got:\n%s", backup)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "[http]") || !strings.Contains(string(data), "[profiles.default]") {
+		t.Errorf("expected the file to be rewritten with profiles, got:\n%s", data)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("expected the token to move out of the config file, got:\n%s", data)
+	}
+
+	// Loading the migrated file again gives the same settings
+	again, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := again.UseStoredToken(); err != nil {
+		t.Fatal(err)
+	}
+	if again.HTTP.Headers["Authorization"] != "X-CLI secret" {
+		t.Errorf("unexpected settings after reload %+v", again.HTTP)
+	}
+}
+
+func TestResolveLeavesFileUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".ck-cli.toml")
+	legacy := `[http]
+endpoint = "https://staging.example.com/graphql"
+
+[http.headers]
+Authorization = "X-CLI secret"
+`
+	writeFile(t, path, legacy)
+
+	cfg, err := Resolve(ResolveOptions{
+		UserPath:   path,
+		SystemPath: filepath.Join(dir, "missing.toml"),
+		WorkDir:    dir,
+		Getenv:     func(string) string { return "" },
+	})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if cfg.ProfileName() != DefaultProfileName || cfg.HTTP.Headers["Authorization"] != "X-CLI secret" {
+		t.Errorf("expected the legacy block to be read as the default profile, got %+v", cfg.HTTP)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != legacy {
+		t.Errorf("expected the file to be left unchanged, got:\n%s", data)
+	}
+	if _, err := os.Stat(path + ".bak"); err == nil {
+		t.Error("expected no backup without a migration")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	cfg := NewConfig()
+	cfg.UpdateToken("personal-token")
+
+	if err := cfg.AddProfile("staging", Profile{Endpoint: "https://staging.example.com/graphql", Token: "staging-token"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.AddProfile("staging", Profile{}, false); err == nil {
+		t.Error("expected an error adding an existing profile")
+	}
+	if err := cfg.AddProfile("bad name", Profile{}, false); err == nil {
+		t.Error("expected an error for an invalid profile name")
+	}
+
+	if err := cfg.UseProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Endpoint != "https://staging.example.com/graphql" || cfg.HTTP.Headers["Authorization"] != "X-CLI staging-token" {
+		t.Errorf("unexpected staging settings %+v", cfg.HTTP)
+	}
+	if err := cfg.UseProfile("missing"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+
+	if err := cfg.RemoveProfile(DefaultProfileName); err == nil {
+		t.Error("expected an error removing the default profile")
+	}
+	if err := cfg.SetDefaultProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.RemoveProfile(DefaultProfileName); err != nil {
+		t.Fatal(err)
+	}
+	if names := cfg.ProfileNames(); len(names) != 1 || names[0] != "staging" {
+		t.Errorf("unexpected profiles %v", names)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"unknown key", "[sync]\nvisiblity = \"private\"\n", `line 2: unknown key "sync.visiblity", did you mean "visibility"?`},
+		{"unknown profile key", "[profiles.work]\nendpoit = \"https://example.com\"\n", `did you mean "endpoint"?`},
+		{"bad URL", "[profiles.work]\nendpoint = \"example.com/graphql\"\n", "profiles.work.endpoint: invalid URL"},
+		{"bad header", "[profiles.work.headers]\n\"X Team\" = \"books\"\n", `invalid header name "X Team"`},
+		{"bad visibility", "[sync]\nvisibility = \"hidden\"\n", `invalid visibility "hidden"`},
+		{"syntax", "[sync\n", "line 1"},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		if err := os.WriteFile(path, []byte(test.data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if err == nil || !strings.Contains(err.Error(), test.expected) || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+func TestLoadDoesNotCreateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ProfileName() != DefaultProfileName {
+		t.Errorf("expected the default profile, got %q", cfg.ProfileName())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no file to be created, got %v", err)
+	}
+}
+
+func TestSetAndUnset(t *testing.T) {
+	cfg := NewConfig()
+	if err := cfg.AddProfile("work", Profile{Endpoint: "https://work.example/graphql"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := map[string]string{
+		"sync.visibility":              "private",
+		"profiles.work.endpoint":       "https://other.example/graphql",
+		"profiles.work.headers.X-Team": "books",
+		"library.input":                "dir:~/kindle",
+		"credentials.encrypt":          "true",
+		"default_profile":              "work",
+	}
+	for key, value := range valid {
+		if err := cfg.Set(key, value); err != nil {
+			t.Errorf("Set(%q) failed: %v", key, err)
+		}
+		if got, err := cfg.Get(key); err != nil || got != value {
+			t.Errorf("Get(%q) = %q, %v", key, got, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"sync.visibility":              "hidden",
+		"profiles.work.endpoint":       "ftp://work.example",
+		"profiles.missing.endpoint":    "https://example.com",
+		"profiles.work.headers.X Team": "books",
+		"profiles.work.token":          "secret",
+		"default_profile":              "missing",
+		"credentials.encrypt":          "maybe",
+		"unknown.key":                  "value",
+	}
+	for key, value := range invalid {
+		if err := cfg.Set(key, value); err == nil {
+			t.Errorf("expected Set(%q, %q) to fail", key, value)
+		}
+	}
+
+	if err := cfg.Unset("profiles.work.headers.x-team"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cfg.Get("profiles.work.headers.X-Team"); got != "" {
+		t.Errorf("expected the header to be removed, got %q", got)
+	}
+	if err := cfg.Unset("default_profile"); err == nil {
+		t.Error("expected an error unsetting default_profile")
+	}
+}
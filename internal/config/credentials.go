@@ -0,0 +1,338 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	// CredentialsFileName is the default credentials file, kept next to the
+	// user config file
+	CredentialsFileName = ".ck-cli.credentials.toml"
+	// EnvPassphrase holds the passphrase of an encrypted credentials file
+	EnvPassphrase = "CK_CLI_PASSPHRASE"
+)
+
+// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256
+var pbkdf2Iterations = 600_000
+
+// CredentialsConfig selects where profile tokens are stored. It is only
+// read from the user config file: a project file must not be able to run a
+// helper or move tokens elsewhere.
+type CredentialsConfig struct {
+	// Helper is a command run as "<helper> get|store|erase" that keeps the
+	// tokens instead of the credentials file
+	Helper string `toml:"helper,omitempty"`
+	// File overrides the credentials file path
+	File string `toml:"file,omitempty"`
+	// Encrypt encrypts tokens in the credentials file with the passphrase
+	// from $CK_CLI_PASSPHRASE
+	Encrypt bool `toml:"encrypt,omitempty"`
+}
+
+// CredentialStore keeps profile tokens outside the config file. Tokens are
+// bound to the endpoint they were stored for: Get returns "" when the
+// profile now points to another endpoint.
+type CredentialStore interface {
+	Get(profile, endpoint string) (string, error)
+	Store(profile, endpoint, token string) error
+	Erase(profile, endpoint string) error
+	// String describes the store for 'ck-cli config show'
+	String() string
+}
+
+// Store returns the credential store configured for the user config file
+// at configPath
+func (c CredentialsConfig) Store(configPath string) (CredentialStore, error) {
+	if c.Helper != "" {
+		return &HelperStore{Command: c.Helper}, nil
+	}
+
+	path := c.File
+	if path == "" {
+		path = filepath.Join(filepath.Dir(configPath), CredentialsFileName)
+	}
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{Path: path, Encrypt: c.Encrypt, Passphrase: os.Getenv(EnvPassphrase)}, nil
+}
+
+// FileStore keeps tokens in a TOML file readable only by its owner,
+// optionally encrypted with AES-GCM under a PBKDF2-derived key
+type FileStore struct {
+	Path       string
+	Encrypt    bool
+	Passphrase string
+}
+
+type credentialsFile struct {
+	Profiles map[string]*storedCredential `toml:"profiles"`
+}
+
+type storedCredential struct {
+	Endpoint       string `toml:"endpoint"`
+	Token          string `toml:"token,omitempty"`
+	EncryptedToken string `toml:"encrypted_token,omitempty"`
+}
+
+// Get returns the token stored for the profile and endpoint
+func (s *FileStore) Get(profile, endpoint string) (string, error) {
+	file, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	entry, ok := file.Profiles[profile]
+	if !ok || entry.Endpoint != endpoint {
+		return "", nil
+	}
+	if entry.EncryptedToken == "" {
+		return entry.Token, nil
+	}
+	if s.Passphrase == "" {
+		return "", fmt.Errorf("the token is encrypted: set %s", EnvPassphrase)
+	}
+	return decryptToken(entry.EncryptedToken, s.Passphrase)
+}
+
+// Store saves the token of the profile, encrypted when configured so
+func (s *FileStore) Store(profile, endpoint, token string) error {
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	entry := &storedCredential{Endpoint: endpoint, Token: token}
+	if s.Encrypt {
+		if s.Passphrase == "" {
+			return fmt.Errorf("credentials encryption is enabled: set %s", EnvPassphrase)
+		}
+		if entry.EncryptedToken, err = encryptToken(token, s.Passphrase); err != nil {
+			return err
+		}
+		entry.Token = ""
+	}
+	file.Profiles[profile] = entry
+	return s.write(file)
+}
+
+// Erase removes the token of the profile
+func (s *FileStore) Erase(profile, endpoint string) error {
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[profile]; !ok {
+		return nil
+	}
+	delete(file.Profiles, profile)
+	return s.write(file)
+}
+
+func (s *FileStore) String() string {
+	if s.Encrypt {
+		return "encrypted file " + s.Path
+	}
+	return "file " + s.Path
+}
+
+func (s *FileStore) read() (*credentialsFile, error) {
+	file := &credentialsFile{}
+	data, err := os.ReadFile(s.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if err := toml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", s.Path, err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = make(map[string]*storedCredential)
+	}
+	return file, nil
+}
+
+func (s *FileStore) write(file *credentialsFile) error {
+	data, err := toml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	if err := writeFileAtomic(s.Path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// encryptToken seals token as base64(salt | nonce | ciphertext)
+func encryptToken(token, passphrase string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := append(salt, nonce...)
+	sealed = gcm.Seal(sealed, nonce, []byte(token), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptToken opens a token sealed by encryptToken
+func decryptToken(encoded, passphrase string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted token: %w", err)
+	}
+	if len(sealed) < 16 {
+		return "", fmt.Errorf("invalid encrypted token: too short")
+	}
+	gcm, err := newGCM(passphrase, sealed[:16])
+	if err != nil {
+		return "", err
+	}
+	sealed = sealed[16:]
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted token: too short")
+	}
+
+	token, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: wrong passphrase or corrupted file")
+	}
+	return string(token), nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// HelperStore delegates tokens to an external command, like git credential
+// helpers. The command is run with the action as last argument and reads
+// "key=value" lines terminated by an empty line on stdin:
+//
+//	profile=default
+//	endpoint=https://clippingkk-api.annatarhe.com/api/v2/graphql
+//	token=...            (store only)
+//
+// For get it prints "token=..." on stdout, or nothing when it has no token.
+type HelperStore struct {
+	Command string
+}
+
+// Get asks the helper for the token of the profile
+func (s *HelperStore) Get(profile, endpoint string) (string, error) {
+	out, err := s.run("get", profile, endpoint, "")
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok && key == "token" {
+			return value, nil
+		}
+	}
+	return "", nil
+}
+
+// Store hands the token of the profile to the helper
+func (s *HelperStore) Store(profile, endpoint, token string) error {
+	_, err := s.run("store", profile, endpoint, token)
+	return err
+}
+
+// Erase asks the helper to forget the token of the profile
+func (s *HelperStore) Erase(profile, endpoint string) error {
+	_, err := s.run("erase", profile, endpoint, "")
+	return err
+}
+
+func (s *HelperStore) String() string {
+	return "helper " + s.Command
+}
+
+func (s *HelperStore) run(action, profile, endpoint, token string) ([]byte, error) {
+	args := strings.Fields(s.Command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty credential helper")
+	}
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "profile=%s\nendpoint=%s\n", profile, endpoint)
+	if token != "" {
+		fmt.Fprintf(&input, "token=%s\n", token)
+	}
+	input.WriteString("\n")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], append(args[1:], action)...)
+	cmd.Stdin = strings.NewReader(input.String())
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credential helper %q %s failed: %w: %s", args[0], action, err, msg)
+		}
+		return nil, fmt.Errorf("credential helper %q %s failed: %w", args[0], action, err)
+	}
+	return out, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a partial file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSaveKeepsTokensInCredentialsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+
+	cfg := NewConfig()
+	cfg.UpdateToken("personal-token")
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "personal-token") {
+		t.Errorf("expected no token in the config file, got:\n%s", data)
+	}
+
+	credentials := filepath.Join(dir, CredentialsFileName)
+	info, err := os.Stat(credentials)
+	if err != nil {
+		t.Fatalf("expected a credentials file: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected credentials mode 0600, got %v", info.Mode().Perm())
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HasToken() {
+		t.Error("expected Load to leave the stored token alone")
+	}
+	if err := loaded.UseStoredToken(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HTTP.Headers["Authorization"] != "X-CLI personal-token" {
+		t.Errorf("unexpected stored token %+v", loaded.HTTP)
+	}
+
+	// The token is bound to the endpoint it was stored for
+	loaded.Profiles[DefaultProfileName].Endpoint = "https://elsewhere.example/graphql"
+	if err := loaded.UseProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.UseStoredToken(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HasToken() {
+		t.Errorf("expected no token for another endpoint, got %+v", loaded.HTTP)
+	}
+}
+
+func TestEncryptedCredentials(t *testing.T) {
+	defer func(iterations int) { pbkdf2Iterations = iterations }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	path := filepath.Join(t.TempDir(), CredentialsFileName)
+	store := &FileStore{Path: path, Encrypt: true, Passphrase: "correct horse"}
+	if err := store.Store("default", DefaultEndpoint, "secret-token"); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-token") || !strings.Contains(string(data), "encrypted_token") {
+		t.Errorf("expected an encrypted token, got:\n%s", data)
+	}
+
+	if token, err := store.Get("default", DefaultEndpoint); err != nil || token != "secret-token" {
+		t.Errorf("Get = %q, %v", token, err)
+	}
+	for _, passphrase := range []string{"", "wrong"} {
+		other := &FileStore{Path: path, Passphrase: passphrase}
+		if _, err := other.Get("default", DefaultEndpoint); err == nil {
+			t.Errorf("expected an error with passphrase %q", passphrase)
+		}
+	}
+
+	if err := (&FileStore{Path: path, Encrypt: true}).Store("default", DefaultEndpoint, "x"); err == nil {
+		t.Error("expected an error storing without a passphrase")
+	}
+}
+
+func TestHelperStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper script needs a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	helper := filepath.Join(dir, "helper")
+	script := `#!/bin/sh
+cat >> "` + log + `"
+echo "action=$1" >> "` + log + `"
+if [ "$1" = get ]; then echo token=from-helper; fi
+`
+	if err := os.WriteFile(helper, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := CredentialsConfig{Helper: helper}.Store(filepath.Join(dir, "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := store.Get("work", "https://work.example/graphql"); err != nil || token != "from-helper" {
+		t.Errorf("Get = %q, %v", token, err)
+	}
+	if err := store.Store("work", "https://work.example/graphql", "new-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"profile=work\n", "endpoint=https://work.example/graphql\n", "action=get", "token=new-token\n", "action=store"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected helper input %q in:\n%s", want, data)
+		}
+	}
+
+	if _, err := (&HelperStore{Command: filepath.Join(dir, "missing")}).Get("work", ""); err == nil {
+		t.Error("expected an error for a missing helper")
+	}
+}
+
+func TestForgetTokenErasesStoredToken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+
+	cfg := NewConfig()
+	cfg.UpdateToken("personal-token")
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.ForgetToken()
+	if cfg.HasToken() {
+		t.Errorf("expected no token after ForgetToken, got %+v", cfg.HTTP)
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, CredentialsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "personal-token") {
+		t.Errorf("expected the stored token to be erased, got:\n%s", data)
+	}
+}
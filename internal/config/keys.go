@@ -0,0 +1,313 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/clippingkk/cli/pkg/ckk"
+)
+
+// Keys lists the keys accepted by Get, Set and Unset; <name> is a profile
+// name and <header> an HTTP header name
+var Keys = []string{
+	"default_profile",
+	"profiles.<name>.endpoint",
+	"profiles.<name>.headers.<header>",
+	"profiles.<name>.auth",
+	"profiles.<name>.username",
+	"profiles.<name>.oauth2.client_id",
+	"profiles.<name>.oauth2.device_auth_url",
+	"profiles.<name>.oauth2.token_url",
+	"profiles.<name>.mtls.cert_file",
+	"profiles.<name>.mtls.key_file",
+	"http.tls.ca_file",
+	"http.tls.insecure_skip_verify",
+	"http.proxy.url",
+	"sync.visibility",
+	"books.mapping_file",
+	"books.cache_file",
+	"library.input",
+	"credentials.helper",
+	"credentials.file",
+	"credentials.encrypt",
+}
+
+// Get returns the value of a key such as "sync.visibility" or
+// "profiles.work.endpoint", "" when it is not set
+func (c *Config) Get(key string) (string, error) {
+	if field := c.boolField(key); field != nil {
+		return strconv.FormatBool(*field), nil
+	}
+	if name, header, ok := headerKey(key); ok {
+		profile, ok := c.Profiles[name]
+		if !ok {
+			return "", fmt.Errorf("unknown profile %q", name)
+		}
+		for h, value := range profile.Headers {
+			if strings.EqualFold(h, header) {
+				return value, nil
+			}
+		}
+		return "", nil
+	}
+
+	field, err := c.field(key)
+	if err != nil {
+		return "", err
+	}
+	if name, _, ok := profileKey(key); ok {
+		defer c.Profiles[name].compact()
+	}
+	return *field, nil
+}
+
+// Set changes the value of a key, rejecting invalid values
+func (c *Config) Set(key, value string) error {
+	if field := c.boolField(key); field != nil {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s: expected true or false", value, key)
+		}
+		*field = enabled
+		c.HTTP.TransportConfig = c.Transport
+		return nil
+	}
+	if name, header, ok := headerKey(key); ok {
+		profile, ok := c.Profiles[name]
+		if !ok {
+			return fmt.Errorf("unknown profile %q", name)
+		}
+		if err := ValidateHeader(header, value); err != nil {
+			return err
+		}
+		if strings.EqualFold(header, "Authorization") && profile.Token != "" {
+			return fmt.Errorf("profile %q authenticates with a token, run 'ck-cli logout' first", name)
+		}
+		deleteHeader(profile.Headers, header)
+		if profile.Headers == nil {
+			profile.Headers = make(map[string]string)
+		}
+		profile.Headers[header] = value
+		c.refresh(name)
+		return nil
+	}
+
+	field, err := c.field(key)
+	if err != nil {
+		return err
+	}
+	if name, _, ok := profileKey(key); ok {
+		defer c.Profiles[name].compact()
+	}
+	switch {
+	case key == "default_profile":
+		if _, ok := c.Profiles[value]; !ok {
+			return fmt.Errorf("unknown profile %q (available: %s)", value, strings.Join(c.ProfileNames(), ", "))
+		}
+	case key == "sync.visibility":
+		if _, err := parseVisibility(value); err != nil {
+			return err
+		}
+	case strings.HasSuffix(key, ".endpoint"), strings.HasSuffix(key, "_url"):
+		if err := ValidateEndpoint(value); err != nil {
+			return err
+		}
+	case key == "http.proxy.url":
+		if err := ckk.ValidateProxy(value); err != nil {
+			return err
+		}
+	case strings.HasSuffix(key, ".auth"):
+		if !slices.Contains(authSchemes, value) {
+			return fmt.Errorf("invalid scheme %q for %s, expected one of %s", value, key, strings.Join(authSchemes, ", "))
+		}
+	}
+	*field = value
+	if name, _, ok := profileKey(key); ok {
+		c.refresh(name)
+	}
+	c.HTTP.TransportConfig = c.Transport
+	return nil
+}
+
+// Unset removes a key, so the default or a lower config layer applies
+func (c *Config) Unset(key string) error {
+	if field := c.boolField(key); field != nil {
+		*field = false
+		c.HTTP.TransportConfig = c.Transport
+		return nil
+	}
+	if key == "default_profile" {
+		return fmt.Errorf("default_profile cannot be unset, use 'ck-cli profile use' to change it")
+	}
+	if name, header, ok := headerKey(key); ok {
+		profile, ok := c.Profiles[name]
+		if !ok {
+			return fmt.Errorf("unknown profile %q", name)
+		}
+		deleteHeader(profile.Headers, header)
+		c.refresh(name)
+		return nil
+	}
+
+	field, err := c.field(key)
+	if err != nil {
+		return err
+	}
+	*field = ""
+	if name, _, ok := profileKey(key); ok {
+		c.refresh(name)
+	}
+	c.HTTP.TransportConfig = c.Transport
+	return nil
+}
+
+// field returns the string setting named by key
+func (c *Config) field(key string) (*string, error) {
+	switch key {
+	case "default_profile":
+		return &c.DefaultProfile, nil
+	case "sync.visibility":
+		return &c.Sync.Visibility, nil
+	case "books.mapping_file":
+		return &c.Books.MappingFile, nil
+	case "books.cache_file":
+		return &c.Books.CacheFile, nil
+	case "library.input":
+		return &c.Library.Input, nil
+	case "credentials.helper":
+		return &c.Credentials.Helper, nil
+	case "credentials.file":
+		return &c.Credentials.File, nil
+	case "http.tls.ca_file":
+		return &c.Transport.TLS.CAFile, nil
+	case "http.proxy.url":
+		return &c.Transport.Proxy.URL, nil
+	}
+
+	if name, setting, ok := profileKey(key); ok {
+		profile, ok := c.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q, add it with 'ck-cli profile add'", name)
+		}
+		return profile.field(setting), nil
+	}
+
+	switch {
+	case strings.HasPrefix(key, "profiles.") && strings.HasSuffix(key, ".token"):
+		return nil, fmt.Errorf("tokens are not part of the config file, use 'ck-cli login' and 'ck-cli logout'")
+	case strings.HasPrefix(key, "profiles.") && strings.HasSuffix(key, ".oauth2.scopes"):
+		return nil, fmt.Errorf("oauth2.scopes is a list, edit it in the config file")
+	case key == "http.tls.pinned_keys", key == "http.proxy.no_proxy":
+		return nil, fmt.Errorf("%s is a list, edit it in the config file", key)
+	case strings.HasPrefix(key, "sync.rules"):
+		return nil, fmt.Errorf("sync.rules is a list, edit it in the config file")
+	}
+	return nil, fmt.Errorf("unknown key %q, expected one of:\n  %s", key, strings.Join(Keys, "\n  "))
+}
+
+// boolField returns the boolean setting named by key, nil for other keys
+func (c *Config) boolField(key string) *bool {
+	switch key {
+	case "credentials.encrypt":
+		return &c.Credentials.Encrypt
+	case "http.tls.insecure_skip_verify":
+		return &c.Transport.TLS.InsecureSkipVerify
+	}
+	return nil
+}
+
+// profileSettings are the string settings of a profile, as key suffixes
+var profileSettings = []string{
+	"endpoint", "auth", "username",
+	"oauth2.client_id", "oauth2.device_auth_url", "oauth2.token_url",
+	"mtls.cert_file", "mtls.key_file",
+}
+
+// profileKey splits a "profiles.<name>.<setting>" key naming one of
+// profileSettings
+func profileKey(key string) (name, setting string, ok bool) {
+	rest, ok := strings.CutPrefix(key, "profiles.")
+	if !ok {
+		return "", "", false
+	}
+	for _, setting := range profileSettings {
+		if name, ok := strings.CutSuffix(rest, "."+setting); ok && name != "" {
+			return name, setting, true
+		}
+	}
+	return "", "", false
+}
+
+// field returns the setting of profileSettings, adding the [oauth2] or
+// [mtls] table it belongs to; compact drops it again when left empty
+func (p *Profile) field(setting string) *string {
+	if strings.HasPrefix(setting, "oauth2.") && p.OAuth2 == nil {
+		p.OAuth2 = &OAuth2Config{}
+	}
+	if strings.HasPrefix(setting, "mtls.") && p.MTLS == nil {
+		p.MTLS = &MTLSConfig{}
+	}
+	switch setting {
+	case "endpoint":
+		return &p.Endpoint
+	case "auth":
+		return &p.Scheme
+	case "username":
+		return &p.Username
+	case "oauth2.client_id":
+		return &p.OAuth2.ClientID
+	case "oauth2.device_auth_url":
+		return &p.OAuth2.DeviceAuthURL
+	case "oauth2.token_url":
+		return &p.OAuth2.TokenURL
+	case "mtls.cert_file":
+		return &p.MTLS.CertFile
+	case "mtls.key_file":
+		return &p.MTLS.KeyFile
+	}
+	panic("unknown profile setting " + setting)
+}
+
+// compact removes empty [oauth2] and [mtls] tables
+func (p *Profile) compact() {
+	if p.OAuth2 != nil && p.OAuth2.ClientID == "" && p.OAuth2.DeviceAuthURL == "" && p.OAuth2.TokenURL == "" && len(p.OAuth2.Scopes) == 0 {
+		p.OAuth2 = nil
+	}
+	if p.MTLS != nil && *p.MTLS == (MTLSConfig{}) {
+		p.MTLS = nil
+	}
+}
+
+// headerKey splits a "profiles.<name>.headers.<header>" key
+func headerKey(key string) (name, header string, ok bool) {
+	rest, ok := strings.CutPrefix(key, "profiles.")
+	if !ok {
+		return "", "", false
+	}
+	name, header, ok = strings.Cut(rest, ".headers.")
+	return name, header, ok && name != "" && header != ""
+}
+
+// deleteHeader removes a header whatever its case
+func deleteHeader(headers map[string]string, name string) {
+	for h := range headers {
+		if strings.EqualFold(h, name) {
+			delete(headers, h)
+		}
+	}
+}
+
+// refresh drops empty tables of the named profile and reloads the HTTP
+// settings when it is active
+func (c *Config) refresh(name string) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return
+	}
+	profile.compact()
+	if c.profile == name {
+		c.activate(profile)
+	}
+}
@@ -0,0 +1,431 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Environment variables overriding the config files
+const (
+	EnvConfig     = "CK_CLI_CONFIG"
+	EnvProfile    = "CK_CLI_PROFILE"
+	EnvEndpoint   = "CK_CLI_ENDPOINT"
+	EnvToken      = "CK_CLI_TOKEN"
+	EnvVisibility = "CK_CLI_VISIBILITY"
+	EnvLibrary    = "CK_CLI_LIBRARY"
+)
+
+// SystemConfigPath is the machine-wide config file, the lowest file layer
+const SystemConfigPath = "/etc/ck-cli/config.toml"
+
+// Sources of a setting, lowest precedence first
+const (
+	SourceDefault = "default"
+	SourceSystem  = "system"
+	SourceUser    = "user"
+	// SourceCredentials marks tokens read from the credential store
+	SourceCredentials = "credentials"
+	SourceProject     = "project"
+	SourceEnv         = "env"
+	SourceFlag        = "flag"
+)
+
+// Origin tells where a setting came from
+type Origin struct {
+	Source string `json:"source"`
+	// Detail is the file path, environment variable or flag name
+	Detail string `json:"detail,omitempty"`
+}
+
+// String formats the origin as "user (/home/me/.ck-cli.toml)"
+func (o Origin) String() string {
+	if o.Detail == "" {
+		return o.Source
+	}
+	return fmt.Sprintf("%s (%s)", o.Source, o.Detail)
+}
+
+// Overrides holds the values given on the command line
+type Overrides struct {
+	Profile string
+	Token   string
+}
+
+// ResolveOptions locates the config layers
+type ResolveOptions struct {
+	// UserPath is the user config file, see GetConfigPath
+	UserPath string
+	// SystemPath defaults to SystemConfigPath
+	SystemPath string
+	// WorkDir is where the search for a project .ck-cli.toml starts,
+	// defaults to the working directory
+	WorkDir string
+	// Getenv defaults to os.Getenv
+	Getenv func(string) string
+	Flags  Overrides
+}
+
+// Resolve merges every config layer and activates the selected profile.
+// Precedence, highest first: flags, environment variables, the project
+// .ck-cli.toml found in the working directory or a parent, the user config,
+// the system config. A profile token is only kept while its endpoint is not
+// changed by a higher layer, so a project file cannot redirect a token
+// configured elsewhere to another server; for the same reason the tokens of
+// the environment and flags are ignored when the endpoint comes from a
+// project file, and the [oauth2] and [mtls] settings of a profile,
+// [http.tls] and [http.proxy] are ignored in project files. Without a token
+// from the environment or flags, the token kept in the credential store of
+// the user config is used.
+//
+// The result is meant for reading; edit the user file with Load and Save.
+func Resolve(opts ResolveOptions) (*Config, error) {
+	getenv := opts.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	layers, err := Layers(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{origins: make(map[string]Origin)}
+	for _, layer := range layers {
+		var layerConfig *Config
+		var err error
+		if layer.Source == SourceUser {
+			if _, statErr := os.Stat(layer.Detail); errors.Is(statErr, os.ErrNotExist) {
+				// Without a user file, tokens may still come from the store
+				if config.store, err = (CredentialsConfig{}).Store(layer.Detail); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			// Reading never migrates the file, only commands editing it do
+			layerConfig, err = Read(layer.Detail)
+			if err == nil {
+				config.store = layerConfig.store
+				if layerConfig.Credentials != (CredentialsConfig{}) {
+					config.Credentials = layerConfig.Credentials
+					config.origins["credentials"] = layer
+				}
+			}
+		} else {
+			layerConfig, err = readLayer(layer.Detail)
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		var invalid *ValidationError
+		if errors.As(err, &invalid) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s config %s: %w", layer.Source, layer.Detail, err)
+		}
+		config.merge(layerConfig, layer)
+	}
+
+	config.ensureProfile()
+
+	profile, origin := opts.Flags.Profile, Origin{Source: SourceFlag, Detail: "--profile"}
+	if profile == "" {
+		profile, origin = getenv(EnvProfile), Origin{Source: SourceEnv, Detail: EnvProfile}
+	}
+	if profile != "" {
+		config.origins["profile"] = origin
+	}
+	if err := config.UseProfile(profile); err != nil {
+		return nil, err
+	}
+
+	if endpoint := getenv(EnvEndpoint); endpoint != "" {
+		config.HTTP.Endpoint = endpoint
+		config.origins["endpoint"] = Origin{Source: SourceEnv, Detail: EnvEndpoint}
+	}
+	// Tokens from the environment and flags are not sent to an endpoint
+	// chosen by a project file
+	projectEndpoint := config.origins["endpoint"].Source == "" &&
+		config.origins["profiles."+config.profile+".endpoint"].Source == SourceProject
+	tokenSet := false
+	if token := getenv(EnvToken); token != "" && !projectEndpoint {
+		config.UpdateToken(token)
+		config.origins["token"] = Origin{Source: SourceEnv, Detail: EnvToken}
+		tokenSet = true
+	}
+	if token := opts.Flags.Token; token != "" && !projectEndpoint {
+		config.UpdateToken(token)
+		config.origins["token"] = Origin{Source: SourceFlag, Detail: "--token"}
+		tokenSet = true
+	}
+	if !tokenSet {
+		if err := config.UseStoredToken(); err != nil {
+			return nil, err
+		}
+	}
+	if visibility := getenv(EnvVisibility); visibility != "" {
+		config.Sync.Visibility = visibility
+		config.origins["sync.visibility"] = Origin{Source: SourceEnv, Detail: EnvVisibility}
+	}
+	if input := getenv(EnvLibrary); input != "" {
+		config.Library.Input = input
+		config.origins["library.input"] = Origin{Source: SourceEnv, Detail: EnvLibrary}
+	}
+
+	return config, nil
+}
+
+// Layers returns the config files read by Resolve, lowest precedence
+// first: the system file, the user file and the project file when one is
+// found. The system and user files may not exist.
+func Layers(opts ResolveOptions) ([]Origin, error) {
+	systemPath := opts.SystemPath
+	if systemPath == "" {
+		systemPath = SystemConfigPath
+	}
+
+	layers := []Origin{{Source: SourceSystem, Detail: systemPath}}
+	if opts.UserPath != "" {
+		layers = append(layers, Origin{Source: SourceUser, Detail: opts.UserPath})
+	}
+
+	project, err := findProjectConfig(opts.WorkDir, opts.UserPath)
+	if err != nil {
+		return nil, err
+	}
+	if project != "" {
+		layers = append(layers, Origin{Source: SourceProject, Detail: project})
+	}
+	return layers, nil
+}
+
+// readLayer reads a system or project config file without rewriting it
+func readLayer(path string) (*Config, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := decode(path, data)
+	return config, err
+}
+
+// findProjectConfig looks for .ck-cli.toml in dir and its parents, skipping
+// the user config file itself
+func findProjectConfig(dir, userPath string) (string, error) {
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	userPath, _ = expandHome(userPath)
+	userAbs, _ := filepath.Abs(userPath)
+
+	for {
+		candidate := filepath.Join(dir, ConfigFileName)
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			if abs, _ := filepath.Abs(candidate); abs != userAbs {
+				return candidate, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// merge applies the settings present in layer on top of c
+func (c *Config) merge(layer *Config, origin Origin) {
+	if c.origins == nil {
+		c.origins = make(map[string]Origin)
+	}
+	set := func(key string, dst *string, value string) {
+		if value != "" {
+			*dst = value
+			c.origins[key] = origin
+		}
+	}
+
+	set("default_profile", &c.DefaultProfile, layer.DefaultProfile)
+
+	for name, src := range layer.Profiles {
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]*Profile)
+		}
+		dst, ok := c.Profiles[name]
+		if !ok {
+			dst = &Profile{}
+			c.Profiles[name] = dst
+		}
+		prefix := "profiles." + name + "."
+
+		// A token configured for one server must not follow a new endpoint
+		if src.Endpoint != "" && src.Endpoint != dst.Endpoint && src.Token == "" && dst.Token != "" {
+			dst.Token = ""
+			delete(c.origins, prefix+"token")
+		}
+		set(prefix+"endpoint", &dst.Endpoint, src.Endpoint)
+		set(prefix+"token", &dst.Token, src.Token)
+		set(prefix+"auth", &dst.Scheme, src.Scheme)
+		set(prefix+"username", &dst.Username, src.Username)
+		// A project file must not send tokens to its own authorization
+		// server nor pick the client certificate
+		if origin.Source != SourceProject {
+			if src.OAuth2 != nil {
+				dst.OAuth2 = src.OAuth2
+				c.origins[prefix+"oauth2"] = origin
+			}
+			if src.MTLS != nil {
+				dst.MTLS = src.MTLS
+				c.origins[prefix+"mtls"] = origin
+			}
+		}
+		for header, value := range src.Headers {
+			if dst.Headers == nil {
+				dst.Headers = make(map[string]string)
+			}
+			dst.Headers[header] = value
+			c.origins[prefix+"headers."+header] = origin
+		}
+	}
+
+	// A project file must not weaken the verification of the server nor
+	// route requests through its own proxy
+	if origin.Source != SourceProject {
+		set("http.tls.ca_file", &c.Transport.TLS.CAFile, layer.Transport.TLS.CAFile)
+		if layer.Transport.TLS.InsecureSkipVerify {
+			c.Transport.TLS.InsecureSkipVerify = true
+			c.origins["http.tls.insecure_skip_verify"] = origin
+		}
+		if len(layer.Transport.TLS.PinnedKeys) > 0 {
+			c.Transport.TLS.PinnedKeys = layer.Transport.TLS.PinnedKeys
+			c.origins["http.tls.pinned_keys"] = origin
+		}
+		set("http.proxy.url", &c.Transport.Proxy.URL, layer.Transport.Proxy.URL)
+		if len(layer.Transport.Proxy.NoProxy) > 0 {
+			c.Transport.Proxy.NoProxy = layer.Transport.Proxy.NoProxy
+			c.origins["http.proxy.no_proxy"] = origin
+		}
+	}
+
+	set("sync.visibility", &c.Sync.Visibility, layer.Sync.Visibility)
+	if len(layer.Sync.Rules) > 0 {
+		c.Sync.Rules = layer.Sync.Rules
+		c.origins["sync.rules"] = origin
+	}
+	set("books.mapping_file", &c.Books.MappingFile, layer.Books.MappingFile)
+	set("books.cache_file", &c.Books.CacheFile, layer.Books.CacheFile)
+	set("library.input", &c.Library.Input, layer.Library.Input)
+}
+
+// Setting is one effective configuration value and where it came from
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Origin Origin `json:"origin"`
+}
+
+// Settings lists the effective values of the active profile and the shared
+// sections, with their origins. Secrets are masked.
+func (c *Config) Settings() []Setting {
+	origin := func(keys ...string) Origin {
+		for _, key := range keys {
+			if o, ok := c.origins[key]; ok {
+				return o
+			}
+		}
+		return Origin{Source: SourceDefault}
+	}
+	prefix := "profiles." + c.profile + "."
+
+	settings := []Setting{
+		{Key: "profile", Value: c.profile, Origin: origin("profile", "default_profile")},
+		{Key: "endpoint", Value: c.HTTP.Endpoint, Origin: origin("endpoint", prefix+"endpoint")},
+	}
+
+	settings = append(settings, Setting{Key: "auth", Value: c.HTTP.Auth.EffectiveScheme(), Origin: origin(prefix + "auth")})
+	if username := c.HTTP.Auth.Username; username != "" {
+		settings = append(settings, Setting{Key: "username", Value: username, Origin: origin(prefix + "username")})
+	}
+	if o := c.HTTP.Auth.OAuth2; o != nil {
+		settings = append(settings, Setting{Key: "oauth2.token_url", Value: o.TokenURL, Origin: origin(prefix + "oauth2")})
+	}
+	if m := c.HTTP.Auth.MTLS; m != nil {
+		settings = append(settings, Setting{Key: "mtls.cert_file", Value: m.CertFile, Origin: origin(prefix + "mtls")})
+	}
+
+	token := "(not set)"
+	if value := c.AccessToken(); value != "" {
+		token = mask(value)
+	}
+	settings = append(settings, Setting{Key: "token", Value: token, Origin: origin("token", prefix+"token")})
+
+	var headers []string
+	if profile, ok := c.Profiles[c.profile]; ok {
+		for name := range profile.Headers {
+			headers = append(headers, name)
+		}
+	}
+	sort.Strings(headers)
+	for _, name := range headers {
+		value := c.Profiles[c.profile].Headers[name]
+		if strings.EqualFold(name, "Authorization") {
+			value = mask(value)
+		}
+		settings = append(settings, Setting{Key: "headers." + name, Value: value, Origin: origin(prefix + "headers." + name)})
+	}
+
+	if tls := c.HTTP.TLS; tls.CAFile != "" || tls.InsecureSkipVerify || len(tls.PinnedKeys) > 0 {
+		settings = append(settings,
+			Setting{Key: "http.tls.ca_file", Value: tls.CAFile, Origin: origin("http.tls.ca_file")},
+			Setting{Key: "http.tls.insecure_skip_verify", Value: fmt.Sprint(tls.InsecureSkipVerify), Origin: origin("http.tls.insecure_skip_verify")},
+			Setting{Key: "http.tls.pinned_keys", Value: fmt.Sprintf("%d keys", len(tls.PinnedKeys)), Origin: origin("http.tls.pinned_keys")},
+		)
+	}
+	if proxy := c.HTTP.Proxy; proxy.URL != "" || len(proxy.NoProxy) > 0 {
+		settings = append(settings,
+			Setting{Key: "http.proxy.url", Value: redactedProxy(proxy.URL), Origin: origin("http.proxy.url")},
+			Setting{Key: "http.proxy.no_proxy", Value: strings.Join(proxy.NoProxy, ","), Origin: origin("http.proxy.no_proxy")},
+		)
+	}
+
+	visibility := c.Sync.Visibility
+	if visibility == "" {
+		visibility = VisibilityPublic
+	}
+	settings = append(settings,
+		Setting{Key: "sync.visibility", Value: visibility, Origin: origin("sync.visibility")},
+		Setting{Key: "sync.rules", Value: fmt.Sprintf("%d rules", len(c.Sync.Rules)), Origin: origin("sync.rules")},
+	)
+
+	mappingFile, _ := c.Books.ResolvedMappingFile()
+	cacheFile, _ := c.Books.ResolvedCacheFile()
+	settings = append(settings,
+		Setting{Key: "books.mapping_file", Value: mappingFile, Origin: origin("books.mapping_file")},
+		Setting{Key: "books.cache_file", Value: cacheFile, Origin: origin("books.cache_file")},
+		Setting{Key: "library.input", Value: c.Library.Input, Origin: origin("library.input")},
+	)
+	if c.store != nil {
+		settings = append(settings, Setting{Key: "credentials", Value: c.store.String(), Origin: origin("credentials")})
+	}
+	return settings
+}
+
+// mask hides all but the first characters of a secret
+func mask(secret string) string {
+	const visible = 4
+	if len(secret) <= visible*2 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:visible] + strings.Repeat("*", 8)
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	systemPath := filepath.Join(dir, "etc", "config.toml")
+	userPath := filepath.Join(dir, "home", ".ck-cli.toml")
+	projectDir := filepath.Join(dir, "work", "notes")
+	workDir := filepath.Join(projectDir, "sub")
+
+	writeFile(t, systemPath, `
+[profiles.default]
+endpoint = "https://system.example/graphql"
+
+[books]
+cache_file = "/var/cache/ck-cli/books.json"
+`)
+	writeFile(t, userPath, `
+default_profile = "default"
+
+[profiles.default]
+endpoint = "https://user.example/graphql"
+token = "user-token"
+
+[profiles.staging]
+endpoint = "https://staging.example/graphql"
+token = "staging-token"
+
+[sync]
+visibility = "private"
+`)
+	writeFile(t, filepath.Join(projectDir, ConfigFileName), `
+[library]
+input = "dir:./backups"
+
+[profiles.staging]
+endpoint = "https://evil.example/graphql"
+`)
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{}
+	resolve := func(flags Overrides) *Config {
+		t.Helper()
+		cfg, err := Resolve(ResolveOptions{
+			UserPath:   userPath,
+			SystemPath: systemPath,
+			WorkDir:    workDir,
+			Getenv:     func(key string) string { return env[key] },
+			Flags:      flags,
+		})
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		return cfg
+	}
+
+	cfg := resolve(Overrides{})
+	if cfg.HTTP.Endpoint != "https://user.example/graphql" || cfg.HTTP.Headers["Authorization"] != "X-CLI user-token" {
+		t.Errorf("expected the user profile over the system one, got %+v", cfg.HTTP)
+	}
+	if cfg.Books.CacheFile != "/var/cache/ck-cli/books.json" || cfg.Sync.Visibility != VisibilityPrivate {
+		t.Errorf("expected settings of every layer, got %+v %+v", cfg.Books, cfg.Sync)
+	}
+	if cfg.Library.Input != "dir:./backups" {
+		t.Errorf("expected the project library, got %q", cfg.Library.Input)
+	}
+
+	origins := map[string]Origin{}
+	for _, s := range cfg.Settings() {
+		origins[s.Key] = s.Origin
+	}
+	if origins["endpoint"].Source != SourceUser || origins["books.cache_file"].Source != SourceSystem ||
+		origins["library.input"].Source != SourceProject || origins["profile"].Source != SourceUser {
+		t.Errorf("unexpected origins %+v", origins)
+	}
+
+	// The project file moved the staging endpoint, so its token is dropped
+	cfg = resolve(Overrides{Profile: "staging"})
+	if cfg.HTTP.Endpoint != "https://evil.example/graphql" || cfg.HasToken() {
+		t.Errorf("expected the project endpoint without the user token, got %+v", cfg.HTTP)
+	}
+
+	env[EnvProfile] = "staging"
+	env[EnvEndpoint] = "https://env.example/graphql"
+	env[EnvToken] = "env-token"
+	cfg = resolve(Overrides{})
+	if cfg.ProfileName() != "staging" || cfg.HTTP.Endpoint != "https://env.example/graphql" || cfg.HTTP.Headers["Authorization"] != "X-CLI env-token" {
+		t.Errorf("expected environment overrides, got %s %+v", cfg.ProfileName(), cfg.HTTP)
+	}
+
+	cfg = resolve(Overrides{Profile: "default", Token: "flag-token"})
+	if cfg.ProfileName() != "default" || cfg.HTTP.Headers["Authorization"] != "X-CLI flag-token" {
+		t.Errorf("expected flags over the environment, got %s %+v", cfg.ProfileName(), cfg.HTTP)
+	}
+	for _, s := range cfg.Settings() {
+		if s.Key == "token" && (s.Origin.Source != SourceFlag || s.Value == "flag-token") {
+			t.Errorf("expected a masked token from --token, got %+v", s)
+		}
+	}
+}
+
+func TestResolveProjectEndpointDropsEnvToken(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "home", ".ck-cli.toml")
+	workDir := filepath.Join(dir, "work")
+	writeFile(t, filepath.Join(workDir, ConfigFileName), `
+[profiles.default]
+endpoint = "https://evil.example/graphql"
+`)
+
+	env := map[string]string{EnvToken: "env-token"}
+	resolve := func(flags Overrides) *Config {
+		t.Helper()
+		cfg, err := Resolve(ResolveOptions{
+			UserPath:   userPath,
+			SystemPath: filepath.Join(dir, "missing.toml"),
+			WorkDir:    workDir,
+			Getenv:     func(key string) string { return env[key] },
+			Flags:      flags,
+		})
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		return cfg
+	}
+
+	for _, flags := range []Overrides{{}, {Token: "flag-token"}} {
+		cfg := resolve(flags)
+		if cfg.HTTP.Endpoint != "https://evil.example/graphql" || cfg.HasToken() {
+			t.Errorf("expected the project endpoint without a token, got %+v", cfg.HTTP)
+		}
+	}
+
+	// An endpoint chosen in the environment gets the token again
+	env[EnvEndpoint] = "https://env.example/graphql"
+	cfg := resolve(Overrides{})
+	if cfg.HTTP.Endpoint != "https://env.example/graphql" || cfg.HTTP.Headers["Authorization"] != "X-CLI env-token" {
+		t.Errorf("expected the environment token for the environment endpoint, got %+v", cfg.HTTP)
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfileName names the profile of new and migrated config files
+const DefaultProfileName = "default"
+
+// profileNamePattern restricts profile names to what reads well in TOML keys
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Profile is one ClippingKK account: a service endpoint with its credentials
+type Profile struct {
+	Endpoint string            `toml:"endpoint"`
+	Headers  map[string]string `toml:"headers,omitempty"`
+	AuthConfig
+	// Token is the secret of the authentication scheme, by default the
+	// API token sent as "Authorization: X-CLI <token>"
+	Token string `toml:"token,omitempty"`
+}
+
+// HTTPConfig returns the HTTP settings of the profile, with the API token
+// added as Authorization header
+func (p Profile) HTTPConfig() HTTPConfig {
+	http := HTTPConfig{Endpoint: p.endpointOrDefault(), Headers: make(map[string]string, len(p.Headers)+1), Auth: p.AuthConfig}
+	for name, value := range p.Headers {
+		http.Headers[name] = value
+	}
+	if p.Token != "" {
+		http.setToken(p.Token)
+	}
+	return http
+}
+
+// endpointOrDefault returns the endpoint, DefaultEndpoint when unset
+func (p Profile) endpointOrDefault() string {
+	if p.Endpoint == "" {
+		return DefaultEndpoint
+	}
+	return p.Endpoint
+}
+
+// setToken replaces any Authorization header with the API token, or keeps
+// the secret of another scheme for its Auth provider
+func (h *HTTPConfig) setToken(token string) {
+	if h.Auth.EffectiveScheme() != AuthToken {
+		h.secret = token
+		return
+	}
+	if h.Headers == nil {
+		h.Headers = make(map[string]string)
+	}
+	for name := range h.Headers {
+		if strings.EqualFold(name, "Authorization") {
+			delete(h.Headers, name)
+		}
+	}
+	h.Headers["Authorization"] = tokenPrefix + token
+}
+
+// token returns the secret of the scheme, for the default scheme the API
+// token sent in the Authorization header
+func (h HTTPConfig) token() string {
+	if h.Auth.EffectiveScheme() != AuthToken {
+		return h.secret
+	}
+	for name, value := range h.Headers {
+		if strings.EqualFold(name, "Authorization") && strings.HasPrefix(value, tokenPrefix) {
+			return strings.TrimPrefix(value, tokenPrefix)
+		}
+	}
+	return ""
+}
+
+// tokenPrefix precedes API tokens in the Authorization header
+const tokenPrefix = "X-CLI "
+
+// ProfileNames returns the configured profile names in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the named profile
+func (c *Config) Profile(name string) (*Profile, bool) {
+	profile, ok := c.Profiles[name]
+	return profile, ok
+}
+
+// ProfileName returns the name of the active profile
+func (c *Config) ProfileName() string {
+	return c.profile
+}
+
+// UseProfile makes the named profile, or the default profile for "", the
+// active one and loads its settings into HTTP
+func (c *Config) UseProfile(name string) error {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	c.profile = name
+	c.activate(profile)
+	return nil
+}
+
+// activate loads the HTTP settings of the active profile, together with
+// the shared [http.tls] and [http.proxy] settings
+func (c *Config) activate(profile *Profile) {
+	c.HTTP = profile.HTTPConfig()
+	c.HTTP.TransportConfig = c.Transport
+}
+
+// AddProfile adds a profile, or replaces it when replace is set
+func (c *Config) AddProfile(name string, profile Profile, replace bool) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	if _, exists := c.Profiles[name]; exists && !replace {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*Profile)
+	}
+	c.Profiles[name] = &profile
+	if c.DefaultProfile == "" {
+		c.DefaultProfile = name
+	}
+	if c.profile == name {
+		c.activate(&profile)
+	}
+	return nil
+}
+
+// RemoveProfile deletes a profile other than the default one; Save erases
+// its stored token
+func (c *Config) RemoveProfile(name string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	if name == c.DefaultProfile {
+		return fmt.Errorf("cannot remove the default profile %q, switch to another one first", name)
+	}
+	delete(c.Profiles, name)
+	if c.erased == nil {
+		c.erased = make(map[string]string)
+	}
+	c.erased[name] = profile.endpointOrDefault()
+	return nil
+}
+
+// SetDefaultProfile makes the named profile the default
+func (c *Config) SetDefaultProfile(name string) error {
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	c.DefaultProfile = name
+	return nil
+}
+
+// migrateHTTP turns the single [http] block of older config files into a
+// profile; an "X-CLI" Authorization header becomes the profile token
+func migrateHTTP(http HTTPConfig) *Profile {
+	profile := &Profile{Endpoint: http.Endpoint}
+	for name, value := range http.Headers {
+		if strings.EqualFold(name, "Authorization") && strings.HasPrefix(value, tokenPrefix) {
+			profile.Token = strings.TrimPrefix(value, tokenPrefix)
+			continue
+		}
+		if profile.Headers == nil {
+			profile.Headers = make(map[string]string)
+		}
+		profile.Headers[name] = value
+	}
+	return profile
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/clippingkk/cli/pkg/ckk"
+)
+
+// TransportConfig holds the [http.tls] and [http.proxy] sections, shared by
+// every profile
+type TransportConfig struct {
+	TLS   TLSConfig   `toml:"tls,omitempty"`
+	Proxy ProxyConfig `toml:"proxy,omitempty"`
+}
+
+// TLSConfig represents how the server certificate is verified
+type TLSConfig struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string `toml:"ca_file,omitempty"`
+	// InsecureSkipVerify accepts any server certificate, for local testing
+	InsecureSkipVerify bool `toml:"insecure_skip_verify,omitempty"`
+	// PinnedKeys are "sha256/<base64>" public key hashes, one of which the
+	// verified server certificate chain must contain
+	PinnedKeys []string `toml:"pinned_keys,omitempty"`
+}
+
+// ProxyConfig represents the proxy requests go through
+type ProxyConfig struct {
+	// URL is an http://, https://, socks5:// or socks5h:// proxy; when empty
+	// the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables apply
+	URL string `toml:"url,omitempty"`
+	// NoProxy lists hosts, domains and CIDR ranges reached directly
+	NoProxy []string `toml:"no_proxy,omitempty"`
+}
+
+// isZero reports whether no transport setting is given
+func (t TransportConfig) isZero() bool {
+	return t.TLS.CAFile == "" && !t.TLS.InsecureSkipVerify && len(t.TLS.PinnedKeys) == 0 &&
+		t.Proxy.URL == "" && len(t.Proxy.NoProxy) == 0
+}
+
+// validate checks the proxy URL and the pinned keys
+func (t TransportConfig) validate() []string {
+	var problems []string
+	if t.Proxy.URL != "" {
+		if err := ckk.ValidateProxy(t.Proxy.URL); err != nil {
+			problems = append(problems, fmt.Sprintf("http.proxy.url: %v", err))
+		}
+	}
+	for _, pin := range t.TLS.PinnedKeys {
+		if err := ckk.ValidatePin(pin); err != nil {
+			problems = append(problems, fmt.Sprintf("http.tls.pinned_keys: %v", err))
+		}
+	}
+	return problems
+}
+
+// Options returns the client options of the [http.tls] and [http.proxy]
+// sections, reading the CA bundle
+func (t TransportConfig) Options() ([]ckk.Option, error) {
+	var opts []ckk.Option
+
+	tlsOptions := ckk.TLSOptions{InsecureSkipVerify: t.TLS.InsecureSkipVerify, PinnedKeys: t.TLS.PinnedKeys}
+	if t.TLS.CAFile != "" {
+		path, err := expandHome(t.TLS.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		if tlsOptions.RootCAs, err = ckk.LoadCABundle(path); err != nil {
+			return nil, fmt.Errorf("http.tls.ca_file: %w", err)
+		}
+	}
+	if tlsOptions.RootCAs != nil || tlsOptions.InsecureSkipVerify || len(tlsOptions.PinnedKeys) > 0 {
+		opts = append(opts, ckk.WithTLS(tlsOptions))
+	}
+
+	if t.Proxy.URL != "" || len(t.Proxy.NoProxy) > 0 {
+		opts = append(opts, ckk.WithProxy(ckk.ProxyOptions{URL: t.Proxy.URL, NoProxy: t.Proxy.NoProxy}))
+	}
+	return opts, nil
+}
+
+// redactedProxy hides the password of a proxy URL
+func redactedProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return mask(proxy)
+	}
+	return u.Redacted()
+}
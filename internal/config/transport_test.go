@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/clippingkk/cli/pkg/ckk"
+)
+
+func TestTransportSettings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".ck-cli.toml")
+	writeFile(t, path, `
+[profiles.default]
+endpoint = "https://user.example/graphql"
+
+[http.tls]
+ca_file = "/etc/ssl/corp.pem"
+pinned_keys = ["sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="]
+
+[http.proxy]
+url = "socks5://proxy.corp.example:1080"
+no_proxy = [".corp.example"]
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.HTTP.Endpoint != "https://user.example/graphql" || cfg.HTTP.TLS.CAFile != "/etc/ssl/corp.pem" || cfg.HTTP.Proxy.URL != "socks5://proxy.corp.example:1080" {
+		t.Errorf("unexpected HTTP settings %+v", cfg.HTTP)
+	}
+	if _, err := os.Stat(path + ".bak"); err == nil {
+		t.Error("expected [http.tls] and [http.proxy] not to trigger a migration")
+	}
+
+	if err := cfg.Set("http.tls.insecure_skip_verify", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Set("http.proxy.url", "ftp://proxy.example"); err == nil {
+		t.Error("expected an ftp proxy to be rejected")
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	again, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.HTTP.TLS.InsecureSkipVerify || len(again.HTTP.TLS.PinnedKeys) != 1 || len(again.HTTP.Proxy.NoProxy) != 1 {
+		t.Errorf("expected the settings to survive Save, got %+v", again.Transport)
+	}
+
+	writeFile(t, path, `
+[http.tls]
+pinned_keys = ["md5/abc"]
+[http.proxy]
+url = "proxy.example:3128"
+`)
+	_, err = Load(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Problems) != 2 {
+		t.Errorf("expected the pin and the proxy URL to be rejected, got %v", err)
+	}
+}
+
+func TestProjectCannotConfigureTransport(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "home", ".ck-cli.toml")
+	writeFile(t, userPath, `
+[http.proxy]
+url = "http://proxy.corp.example:3128"
+`)
+	writeFile(t, filepath.Join(dir, "work", ConfigFileName), `
+[http.tls]
+insecure_skip_verify = true
+[http.proxy]
+url = "http://evil.example:3128"
+`)
+
+	cfg, err := Resolve(ResolveOptions{
+		UserPath:   userPath,
+		SystemPath: filepath.Join(dir, "missing.toml"),
+		WorkDir:    filepath.Join(dir, "work"),
+		Getenv:     func(string) string { return "" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.TLS.InsecureSkipVerify || cfg.HTTP.Proxy.URL != "http://proxy.corp.example:3128" {
+		t.Errorf("expected the project settings to be ignored, got %+v", cfg.HTTP.TransportConfig)
+	}
+}
+
+func TestTransportOptionsTrustCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"me":{"id":1,"name":"reader"}}}`))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	writeFile(t, caFile, string(certPEM))
+
+	for _, test := range []struct {
+		transport TransportConfig
+		ok        bool
+	}{
+		{TransportConfig{}, false},
+		{TransportConfig{TLS: TLSConfig{CAFile: caFile}}, true},
+		{TransportConfig{TLS: TLSConfig{CAFile: caFile, PinnedKeys: []string{ckk.PublicKeyPin(server.Certificate())}}}, true},
+		{TransportConfig{TLS: TLSConfig{InsecureSkipVerify: true, PinnedKeys: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}}}, false},
+	} {
+		opts, err := test.transport.Options()
+		if err != nil {
+			t.Fatalf("Options failed: %v", err)
+		}
+		_, err = ckk.NewClient(server.URL, opts...).Me(context.Background())
+		if test.ok != (err == nil) {
+			t.Errorf("%+v: unexpected result %v", test.transport.TLS, err)
+		}
+	}
+
+	_, err := TransportConfig{TLS: TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}}.Options()
+	if err == nil || !strings.Contains(err.Error(), "http.tls.ca_file") {
+		t.Errorf("expected a missing CA file to fail, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ValidationError lists every problem found in a config file
+type ValidationError struct {
+	// Path is the config file, empty for a config not read from a file
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	name := "config"
+	if e.Path != "" {
+		name = "config " + e.Path
+	}
+	if len(e.Problems) == 1 {
+		return fmt.Sprintf("invalid %s: %s", name, e.Problems[0])
+	}
+	return fmt.Sprintf("invalid %s:\n  %s", name, strings.Join(e.Problems, "\n  "))
+}
+
+// knownKeys lists the keys of each table, "*" standing for a profile name
+var knownKeys = map[string][]string{
+	"":                  {"default_profile", "profiles", "sync", "books", "library", "credentials", "http"},
+	"profiles.*":        {"endpoint", "headers", "auth", "username", "oauth2", "mtls", "token"},
+	"profiles.*.oauth2": {"client_id", "device_auth_url", "token_url", "scopes"},
+	"profiles.*.mtls":   {"cert_file", "key_file"},
+	"sync":              {"visibility", "rules"},
+	"sync.rules":        {"title", "visibility"},
+	"books":             {"mapping_file", "cache_file"},
+	"library":           {"input"},
+	"credentials":       {"helper", "file", "encrypt"},
+	"http":              {"endpoint", "headers", "tls", "proxy"},
+	"http.tls":          {"ca_file", "insecure_skip_verify", "pinned_keys"},
+	"http.proxy":        {"url", "no_proxy"},
+}
+
+// fileConfig is the document schema: the config plus the [http] block,
+// holding [http.tls] and [http.proxy] and, in older files, the endpoint and
+// headers migrated to a profile
+type fileConfig struct {
+	Config
+	HTTP *HTTPConfig `toml:"http"`
+}
+
+// decodeStrict parses a config file, rejecting unknown keys
+func decodeStrict(data []byte) (*fileConfig, error) {
+	file := &fileConfig{}
+	decoder := toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields()
+	err := decoder.Decode(file)
+	if err == nil {
+		return file, nil
+	}
+
+	var strict *toml.StrictMissingError
+	if errors.As(err, &strict) {
+		problems := make([]string, 0, len(strict.Errors))
+		for _, e := range strict.Errors {
+			row, _ := e.Position()
+			problems = append(problems, fmt.Sprintf("line %d: %s", row, unknownKeyMessage(e.Key())))
+		}
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, column := decodeErr.Position()
+		message := strings.TrimPrefix(decodeErr.Error(), "toml: ")
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("line %d, column %d: %s", row, column, message)}}
+	}
+	return nil, &ValidationError{Problems: []string{err.Error()}}
+}
+
+// unknownKeyMessage describes an unknown key, suggesting the closest known one
+func unknownKeyMessage(key toml.Key) string {
+	message := fmt.Sprintf("unknown key %q", strings.Join(key, "."))
+	if len(key) == 0 {
+		return message
+	}
+
+	table := key[:len(key)-1]
+	if len(table) >= 2 && table[0] == "profiles" {
+		table = append([]string{"profiles", "*"}, table[2:]...)
+	}
+	if suggestion := closest(key[len(key)-1], knownKeys[strings.Join(table, ".")]); suggestion != "" {
+		message += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+	return message
+}
+
+// closest returns the candidate within two edits of word, if any
+func closest(word string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := editDistance(word, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// Validate checks the settings of the config: profile names, endpoint URLs,
+// header names and values, authentication settings, the proxy URL, pinned
+// keys, visibility settings and rules
+func (c *Config) Validate() error {
+	var problems []string
+
+	for _, name := range c.ProfileNames() {
+		profile := c.Profiles[name]
+		prefix := "profiles." + name
+		if !profileNamePattern.MatchString(name) {
+			problems = append(problems, fmt.Sprintf("%s: invalid profile name, use letters, digits, '.', '_' and '-'", prefix))
+		}
+		if profile == nil {
+			continue
+		}
+		if profile.Endpoint != "" {
+			if err := ValidateEndpoint(profile.Endpoint); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.endpoint: %v", prefix, err))
+			}
+		}
+		for header, value := range profile.Headers {
+			if err := ValidateHeader(header, value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.headers: %v", prefix, err))
+			}
+		}
+		problems = append(problems, profile.AuthConfig.validate(prefix)...)
+		if profile.EffectiveScheme() != AuthBasic && strings.ContainsAny(profile.Token, " \t\r\n") {
+			problems = append(problems, fmt.Sprintf("%s.token: must not contain whitespace", prefix))
+		}
+	}
+
+	if c.DefaultProfile != "" && !profileNamePattern.MatchString(c.DefaultProfile) {
+		problems = append(problems, fmt.Sprintf("default_profile: invalid profile name %q", c.DefaultProfile))
+	}
+	problems = append(problems, c.Transport.validate()...)
+	if _, err := c.Sync.Policy(); err != nil {
+		problems = append(problems, fmt.Sprintf("sync: %v", err))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateEndpoint checks that endpoint is an absolute http(s) URL
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL %q: must start with http:// or https://", endpoint)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid URL %q: missing host", endpoint)
+	}
+	return nil
+}
+
+// ValidateHeader checks that name is a valid HTTP header name and value
+// holds no line breaks
+func ValidateHeader(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("empty header name")
+	}
+	for _, r := range name {
+		if !isTokenChar(r) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return fmt.Errorf("header %q: value must not contain line breaks", name)
+	}
+	return nil
+}
+
+// isTokenChar reports whether r may appear in a header name (RFC 9110 tchar)
+func isTokenChar(r rune) bool {
+	if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
+
+// ValidateFile checks a config file without changing it
+func ValidateFile(path string) error {
+	path, err := expandHome(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	_, _, err = decode(path, data)
+	return err
+}
@@ -0,0 +1,172 @@
+package device
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ClippingsFile is the clippings file path relative to a Kindle mount point
+	ClippingsFile = "documents/My Clippings.txt"
+	// SystemDir is the Kindle system directory used to recognise the volume
+	SystemDir = "system"
+	// RootsEnv overrides the scanned mount roots, separated by os.PathListSeparator
+	RootsEnv = "CK_CLI_DEVICE_ROOTS"
+)
+
+// Device is a mounted Kindle volume
+type Device struct {
+	Name          string    `json:"name"`
+	MountPoint    string    `json:"mountPoint"`
+	ClippingsPath string    `json:"clippingsPath"`
+	Size          int64     `json:"size"`
+	ModTime       time.Time `json:"modTime"`
+	// Clippings is the number of entries in the clippings file
+	Clippings int `json:"clippings"`
+}
+
+// DefaultRoots returns the usual Linux mount roots for removable media:
+// /media/$USER, /run/media/$USER, /media and /mnt. RootsEnv replaces them.
+func DefaultRoots() []string {
+	if env := os.Getenv(RootsEnv); env != "" {
+		return filepath.SplitList(env)
+	}
+
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil && username == "" {
+		username = u.Username
+	}
+
+	var roots []string
+	if username != "" {
+		roots = append(roots, filepath.Join("/media", username), filepath.Join("/run/media", username))
+	}
+	return append(roots, "/media", "/mnt")
+}
+
+// Scan looks for Kindle volumes in each root and its direct subdirectories.
+// Roots that do not exist are skipped; the same volume found through
+// several roots is reported once.
+func Scan(roots []string) ([]Device, error) {
+	return scan(roots, Inspect)
+}
+
+// scan looks for Kindle volumes in roots, describing each with inspect
+func scan(roots []string, inspect func(string) (Device, bool)) ([]Device, error) {
+	var devices []Device
+	seen := make(map[string]bool)
+
+	for _, root := range roots {
+		candidates := []string{root}
+		entries, err := os.ReadDir(root)
+		if err != nil && !os.IsNotExist(err) && !os.IsPermission(err) {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || entry.Type()&os.ModeSymlink != 0 {
+				candidates = append(candidates, filepath.Join(root, entry.Name()))
+			}
+		}
+
+		for _, mountPoint := range candidates {
+			device, ok := inspect(mountPoint)
+			if !ok {
+				continue
+			}
+			key := device.MountPoint
+			if resolved, err := filepath.EvalSymlinks(key); err == nil {
+				key = resolved
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			devices = append(devices, device)
+		}
+	}
+
+	// Most recently updated clippings first
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].ModTime.After(devices[j].ModTime)
+	})
+	return devices, nil
+}
+
+// Inspect reports whether mountPoint holds a Kindle layout, a system
+// directory next to documents/My Clippings.txt, and describes it
+func Inspect(mountPoint string) (Device, bool) {
+	device, ok := Stat(mountPoint)
+	if ok {
+		device.count()
+	}
+	return device, ok
+}
+
+// Stat is Inspect without counting the clippings: it only stats the files,
+// so it is cheap enough to poll
+func Stat(mountPoint string) (Device, bool) {
+	system, err := os.Stat(filepath.Join(mountPoint, SystemDir))
+	if err != nil || !system.IsDir() {
+		return Device{}, false
+	}
+
+	clippingsPath := filepath.Join(mountPoint, filepath.FromSlash(ClippingsFile))
+	info, err := os.Stat(clippingsPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return Device{}, false
+	}
+
+	return Device{
+		Name:          filepath.Base(mountPoint),
+		MountPoint:    mountPoint,
+		ClippingsPath: clippingsPath,
+		Size:          info.Size(),
+		ModTime:       info.ModTime(),
+	}, true
+}
+
+// count reads the clippings file to fill in the number of entries
+func (d *Device) count() {
+	if data, err := os.ReadFile(d.ClippingsPath); err == nil {
+		d.Clippings = countEntries(data)
+	}
+}
+
+// Find returns the most recently updated Kindle among roots
+func Find(roots []string) (Device, error) {
+	device, err := Locate(roots)
+	if err != nil {
+		return Device{}, err
+	}
+	device.count()
+	return device, nil
+}
+
+// Locate is Find without counting the clippings, see Stat
+func Locate(roots []string) (Device, error) {
+	devices, err := scan(roots, Stat)
+	if err != nil {
+		return Device{}, err
+	}
+	if len(devices) == 0 {
+		return Device{}, fmt.Errorf("no Kindle found in %s", strings.Join(roots, ", "))
+	}
+	return devices[0], nil
+}
+
+// countEntries counts the "==========" separators closing each clipping
+func countEntries(data []byte) int {
+	count := 0
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("==========")) {
+			count++
+		}
+	}
+	return count
+}
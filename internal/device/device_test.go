@@ -0,0 +1,96 @@
+package device
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const clippings = `Bad Blood (Carreyrou, John)
+- Your Highlight on page 27 | Location 419-419 | Added on Tuesday, February 19, 2019 7:32:33 PM
+
+the reader called a photomultiplier
+==========
+Bad Blood (Carreyrou, John)
+- Your Highlight on page 50 | Location 754-755 | Added on Tuesday, February 26, 2019 9:46:01 AM
+
+Susan was mortified
+==========
+`
+
+// mountKindle simulates a mounted Kindle volume below root
+func mountKindle(t *testing.T, mountPoint string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(mountPoint, SystemDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(mountPoint, filepath.FromSlash(ClippingsFile))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(clippings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	media := t.TempDir()
+	mnt := t.TempDir()
+
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	mountKindle(t, filepath.Join(media, "Kindle"), older)
+	mountKindle(t, mnt, newer)
+
+	// A USB stick without the Kindle layout
+	if err := os.MkdirAll(filepath.Join(media, "USB", "documents"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := Scan([]string{media, mnt, filepath.Join(media, "missing")})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("Expected 2 devices, got %+v", devices)
+	}
+
+	if devices[0].MountPoint != mnt || devices[1].Name != "Kindle" {
+		t.Errorf("Expected newest device first, got %+v", devices)
+	}
+	if devices[1].Clippings != 2 || devices[1].Size != int64(len(clippings)) || !devices[1].ModTime.Equal(older) {
+		t.Errorf("Unexpected device stats: %+v", devices[1])
+	}
+
+	found, err := Find([]string{media})
+	if err != nil || found.Name != "Kindle" || found.Clippings != 2 {
+		t.Errorf("Find returned %+v, %v", found, err)
+	}
+	// Locate only stats the files
+	located, err := Locate([]string{media})
+	if err != nil || located.ClippingsPath != found.ClippingsPath || located.Clippings != 0 {
+		t.Errorf("Locate returned %+v, %v", located, err)
+	}
+	if _, err := Find([]string{filepath.Join(media, "USB")}); err == nil {
+		t.Error("Expected an error when no Kindle is mounted")
+	}
+}
+
+func TestDefaultRootsFromEnv(t *testing.T) {
+	t.Setenv(RootsEnv, "/a"+string(os.PathListSeparator)+"/b")
+	roots := DefaultRoots()
+	if len(roots) != 2 || roots[0] != "/a" || roots[1] != "/b" {
+		t.Errorf("Unexpected roots %v", roots)
+	}
+
+	t.Setenv(RootsEnv, "")
+	t.Setenv("USER", "reader")
+	roots = DefaultRoots()
+	if roots[0] != "/media/reader" || roots[1] != "/run/media/reader" {
+		t.Errorf("Unexpected default roots %v", roots)
+	}
+}
@@ -0,0 +1,152 @@
+package library
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+// Book summarises the clippings of one book
+type Book struct {
+	Title     string `json:"title"`
+	Author    string `json:"author,omitempty"`
+	Clippings int    `json:"clippings"`
+	// Kinds counts clippings by kind; clippings of unknown kind count as highlights
+	Kinds map[string]int `json:"kinds"`
+	// LocationStart and LocationEnd span the locations covered, zero when unknown
+	LocationStart int `json:"locationStart,omitempty"`
+	LocationEnd   int `json:"locationEnd,omitempty"`
+	// FirstAddedAt and LastAddedAt are zero when no clipping has a valid date
+	FirstAddedAt time.Time `json:"firstAddedAt"`
+	LastAddedAt  time.Time `json:"lastAddedAt"`
+}
+
+// Books groups clippings by title, most recently updated book first
+func Books(clippings []models.ClippingItem) []Book {
+	index := make(map[string]int)
+	books := []Book{}
+
+	for _, item := range clippings {
+		key := bookKey(item.Title)
+		i, ok := index[key]
+		if !ok {
+			i = len(books)
+			index[key] = i
+			books = append(books, Book{Title: item.Title, Kinds: make(map[string]int)})
+		}
+
+		book := &books[i]
+		book.Clippings++
+		book.Kinds[kindOf(item)]++
+		if book.Author == "" {
+			book.Author = item.Author
+		}
+		if start, end, ok := Location(item.PageAt); ok {
+			if book.LocationStart == 0 || start < book.LocationStart {
+				book.LocationStart = start
+			}
+			if end > book.LocationEnd {
+				book.LocationEnd = end
+			}
+		}
+		if !HasDate(item) {
+			continue
+		}
+		if book.FirstAddedAt.IsZero() || item.CreatedAt.Before(book.FirstAddedAt) {
+			book.FirstAddedAt = item.CreatedAt
+		}
+		if item.CreatedAt.After(book.LastAddedAt) {
+			book.LastAddedAt = item.CreatedAt
+		}
+	}
+
+	sort.SliceStable(books, func(i, j int) bool {
+		return books[i].LastAddedAt.After(books[j].LastAddedAt)
+	})
+	return books
+}
+
+// Location parses a clipping location such as "#140-141" or "#7" into its
+// first and last position
+func Location(pageAt string) (start, end int, ok bool) {
+	from, to, found := strings.Cut(strings.TrimPrefix(strings.TrimSpace(pageAt), "#"), "-")
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, false
+	}
+	end = start
+	if found {
+		if end, err = strconv.Atoi(to); err != nil || end < start {
+			end = start
+		}
+	}
+	return start, end, true
+}
+
+// ReadingOrder sorts clippings by location, then creation time; clippings
+// without a location come last
+func ReadingOrder(clippings []models.ClippingItem) []models.ClippingItem {
+	sorted := append([]models.ClippingItem(nil), clippings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _, okA := Location(sorted[i].PageAt)
+		b, _, okB := Location(sorted[j].PageAt)
+		if okA != okB {
+			return okA
+		}
+		if a != b {
+			return a < b
+		}
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	return sorted
+}
+
+// kindOf returns the kind of a clipping, KindHighlight when unknown
+func kindOf(item models.ClippingItem) string {
+	if item.Kind == "" {
+		return models.KindHighlight
+	}
+	return item.Kind
+}
+
+// Filter selects clippings; zero fields match everything
+type Filter struct {
+	// Book matches titles case-insensitively
+	Book string
+	// Author matches authors case-insensitively
+	Author string
+	// Since and Until bound the creation time, Until exclusive
+	Since time.Time
+	Until time.Time
+}
+
+// Match reports whether item passes the filter
+func (f Filter) Match(item models.ClippingItem) bool {
+	if f.Book != "" && !strings.EqualFold(strings.TrimSpace(item.Title), strings.TrimSpace(f.Book)) {
+		return false
+	}
+	if f.Author != "" && !strings.EqualFold(strings.TrimSpace(item.Author), strings.TrimSpace(f.Author)) {
+		return false
+	}
+	if !f.Since.IsZero() && item.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !item.CreatedAt.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Select returns the clippings matching f
+func (f Filter) Select(clippings []models.ClippingItem) []models.ClippingItem {
+	selected := make([]models.ClippingItem, 0, len(clippings))
+	for _, item := range clippings {
+		if f.Match(item) {
+			selected = append(selected, item)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,66 @@
+package library
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		input      string
+		start, end int
+		ok         bool
+	}{
+		{"#140-141", 140, 141, true},
+		{"#7", 7, 7, true},
+		{"1005-1007", 1005, 1007, true},
+		{"#20-3", 20, 20, true},
+		{"", 0, 0, false},
+		{"page x", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		start, end, ok := Location(test.input)
+		if start != test.start || end != test.end || ok != test.ok {
+			t.Errorf("Location(%q) = %d, %d, %v, expected %d, %d, %v", test.input, start, end, ok, test.start, test.end, test.ok)
+		}
+	}
+}
+
+func TestBooks(t *testing.T) {
+	at := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	clippings := []models.ClippingItem{
+		{Title: "Dune", Author: "Frank Herbert", Kind: models.KindHighlight, PageAt: "#300-305", CreatedAt: at(2)},
+		{Title: "Dune", Kind: models.KindNote, PageAt: "#120", CreatedAt: at(1)},
+		{Title: "dune", PageAt: "#900-910", CreatedAt: at(3)},
+		{Title: "三体", Author: "刘慈欣", PageAt: "", CreatedAt: at(5)},
+	}
+
+	books := Books(clippings)
+	if len(books) != 2 || books[0].Title != "三体" {
+		t.Fatalf("expected 2 books, most recent first, got %+v", books)
+	}
+
+	dune := books[1]
+	if dune.Author != "Frank Herbert" || dune.Clippings != 3 {
+		t.Errorf("unexpected book %+v", dune)
+	}
+	if dune.Kinds[models.KindHighlight] != 2 || dune.Kinds[models.KindNote] != 1 {
+		t.Errorf("unexpected kinds %v", dune.Kinds)
+	}
+	if dune.LocationStart != 120 || dune.LocationEnd != 910 {
+		t.Errorf("unexpected location span %d-%d", dune.LocationStart, dune.LocationEnd)
+	}
+	if !dune.FirstAddedAt.Equal(at(1)) || !dune.LastAddedAt.Equal(at(3)) {
+		t.Errorf("unexpected date range %v - %v", dune.FirstAddedAt, dune.LastAddedAt)
+	}
+
+	ordered := ReadingOrder(append(clippings[3:], clippings[:3]...))
+	for i, expected := range []string{"#120", "#300-305", "#900-910", ""} {
+		if ordered[i].PageAt != expected {
+			t.Fatalf("unexpected reading order %+v", ordered)
+		}
+	}
+}
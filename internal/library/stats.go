@@ -0,0 +1,173 @@
+package library
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+// Stats summarises reading activity over a set of clippings
+type Stats struct {
+	Clippings  int `json:"clippings"`
+	Highlights int `json:"highlights"`
+	Notes      int `json:"notes"`
+	Books      int `json:"books"`
+	// AverageLength is the mean highlight length in characters; notes and
+	// bookmarks are left out
+	AverageLength float64 `json:"averageLength"`
+	// FirstActivity and LastActivity ignore clippings without a valid date
+	FirstActivity time.Time `json:"firstActivity"`
+	LastActivity  time.Time `json:"lastActivity"`
+	// ActiveDays is the number of distinct days with at least one clipping
+	ActiveDays    int    `json:"activeDays"`
+	LongestStreak Streak `json:"longestStreak"`
+	// LatestStreak is the streak ending on the day of the last activity
+	LatestStreak Streak        `json:"latestStreak"`
+	ByMonth      []PeriodCount `json:"byMonth"`
+	ByWeekday    []PeriodCount `json:"byWeekday"`
+	// PerBook is sorted by clipping count, most highlighted first
+	PerBook []BookStats `json:"perBook"`
+}
+
+// BookStats summarises the clippings of one book
+type BookStats struct {
+	Book
+	Highlights    int     `json:"highlights"`
+	Notes         int     `json:"notes"`
+	AverageLength float64 `json:"averageLength"`
+}
+
+// Streak is a run of consecutive days with clippings
+type Streak struct {
+	Days  int       `json:"days"`
+	Start time.Time `json:"start,omitzero"`
+	End   time.Time `json:"end,omitzero"`
+}
+
+// PeriodCount is the number of clippings in a month ("2006-01") or on a weekday
+type PeriodCount struct {
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+// HasDate reports whether a clipping carries a real creation date; the
+// parser falls back to the Unix epoch when the date cannot be read
+func HasDate(item models.ClippingItem) bool {
+	return !item.CreatedAt.IsZero() && item.CreatedAt.Unix() != 0
+}
+
+// ComputeStats builds per-book and overall statistics
+func ComputeStats(clippings []models.ClippingItem) Stats {
+	stats := Stats{
+		Clippings: len(clippings),
+		ByMonth:   []PeriodCount{},
+		ByWeekday: make([]PeriodCount, 7),
+	}
+	for i := range stats.ByWeekday {
+		// Weeks start on Monday
+		stats.ByWeekday[i].Period = time.Weekday((i + 1) % 7).String()
+	}
+
+	books := Books(clippings)
+	stats.Books = len(books)
+	perBook := make(map[string]*BookStats, len(books))
+	lengths := make(map[string]int, len(books))
+	stats.PerBook = make([]BookStats, len(books))
+	for i, book := range books {
+		stats.PerBook[i] = BookStats{Book: book}
+		perBook[bookKey(book.Title)] = &stats.PerBook[i]
+	}
+
+	months := make(map[string]int)
+	days := make(map[time.Time]bool)
+	totalLength := 0
+
+	for _, item := range clippings {
+		key := bookKey(item.Title)
+		book := perBook[key]
+		switch item.Kind {
+		case models.KindNote:
+			stats.Notes++
+			book.Notes++
+		case models.KindBookmark:
+		default:
+			length := utf8.RuneCountInString(item.Content)
+			totalLength += length
+			lengths[key] += length
+			stats.Highlights++
+			book.Highlights++
+		}
+
+		if !HasDate(item) {
+			continue
+		}
+		if stats.FirstActivity.IsZero() || item.CreatedAt.Before(stats.FirstActivity) {
+			stats.FirstActivity = item.CreatedAt
+		}
+		if item.CreatedAt.After(stats.LastActivity) {
+			stats.LastActivity = item.CreatedAt
+		}
+		months[item.CreatedAt.Format("2006-01")]++
+		stats.ByWeekday[(int(item.CreatedAt.Weekday())+6)%7].Count++
+		days[day(item.CreatedAt)] = true
+	}
+
+	if stats.Highlights > 0 {
+		stats.AverageLength = float64(totalLength) / float64(stats.Highlights)
+	}
+	for key, book := range perBook {
+		if book.Highlights > 0 {
+			book.AverageLength = float64(lengths[key]) / float64(book.Highlights)
+		}
+	}
+	sort.SliceStable(stats.PerBook, func(i, j int) bool {
+		return stats.PerBook[i].Clippings > stats.PerBook[j].Clippings
+	})
+
+	for month, count := range months {
+		stats.ByMonth = append(stats.ByMonth, PeriodCount{Period: month, Count: count})
+	}
+	sort.Slice(stats.ByMonth, func(i, j int) bool {
+		return stats.ByMonth[i].Period < stats.ByMonth[j].Period
+	})
+
+	stats.ActiveDays = len(days)
+	stats.LongestStreak, stats.LatestStreak = streaks(days)
+	return stats
+}
+
+// streaks returns the longest run of consecutive days and the run ending on the last day
+func streaks(days map[time.Time]bool) (longest, latest Streak) {
+	sorted := make([]time.Time, 0, len(days))
+	for d := range days {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	var current Streak
+	for _, d := range sorted {
+		if current.Days > 0 && d.Equal(current.End.AddDate(0, 0, 1)) {
+			current.Days++
+			current.End = d
+		} else {
+			current = Streak{Days: 1, Start: d, End: d}
+		}
+		if current.Days > longest.Days {
+			longest = current
+		}
+	}
+	return longest, current
+}
+
+// day truncates t to its calendar day in its own location
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// bookKey is the key Books groups titles by
+func bookKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
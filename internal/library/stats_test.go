@@ -0,0 +1,65 @@
+package library
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+func TestComputeStats(t *testing.T) {
+	at := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 21, 0, 0, 0, time.UTC) }
+	clippings := []models.ClippingItem{
+		{Title: "Dune", Kind: models.KindHighlight, Content: "Fear is the mind-killer.", CreatedAt: at(1, 1)},
+		{Title: "Dune", Kind: models.KindNote, Content: "Classic", CreatedAt: at(1, 2)},
+		{Title: "dune ", Kind: models.KindHighlight, Content: "Spice", CreatedAt: at(1, 3)},
+		{Title: "三体", Content: "宇宙就是一座黑暗森林", CreatedAt: at(2, 10)},
+		{Title: "三体", Content: "弱小和无知", CreatedAt: at(2, 11)},
+		// Unreadable dates fall back to the epoch and are left out of time statistics
+		{Title: "Undated", Content: "x", CreatedAt: time.Unix(0, 0).UTC()},
+	}
+
+	stats := ComputeStats(clippings)
+
+	if stats.Clippings != 6 || stats.Books != 3 || stats.Highlights != 5 || stats.Notes != 1 {
+		t.Fatalf("unexpected totals %+v", stats)
+	}
+	if !stats.FirstActivity.Equal(at(1, 1)) || !stats.LastActivity.Equal(at(2, 11)) {
+		t.Errorf("unexpected activity range %v - %v", stats.FirstActivity, stats.LastActivity)
+	}
+	if stats.ActiveDays != 5 {
+		t.Errorf("expected 5 active days, got %d", stats.ActiveDays)
+	}
+	if stats.LongestStreak.Days != 3 || !stats.LongestStreak.Start.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected longest streak %+v", stats.LongestStreak)
+	}
+	if stats.LatestStreak.Days != 2 || !stats.LatestStreak.End.Equal(time.Date(2024, 2, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected latest streak %+v", stats.LatestStreak)
+	}
+
+	if len(stats.ByMonth) != 2 || stats.ByMonth[0] != (PeriodCount{"2024-01", 3}) || stats.ByMonth[1] != (PeriodCount{"2024-02", 2}) {
+		t.Errorf("unexpected months %+v", stats.ByMonth)
+	}
+	// 2024-01-01 is a Monday, the first weekday
+	if stats.ByWeekday[0].Period != "Monday" || stats.ByWeekday[0].Count != 1 || stats.ByWeekday[6].Period != "Sunday" {
+		t.Errorf("unexpected weekdays %+v", stats.ByWeekday)
+	}
+
+	top := stats.PerBook[0]
+	if top.Title != "Dune" || top.Clippings != 3 || top.Highlights != 2 || top.Notes != 1 {
+		t.Errorf("unexpected top book %+v", top)
+	}
+	if !top.FirstAddedAt.Equal(at(1, 1)) || !top.LastAddedAt.Equal(at(1, 3)) {
+		t.Errorf("unexpected top book dates %+v", top)
+	}
+	// The note is left out of the average length
+	if top.AverageLength != 14.5 || stats.AverageLength != 9 {
+		t.Errorf("unexpected average lengths %v and %v", top.AverageLength, stats.AverageLength)
+	}
+	if got := stats.PerBook[1].AverageLength; got != 7.5 {
+		t.Errorf("expected average length 7.5 characters for 三体, got %v", got)
+	}
+	if undated := stats.PerBook[2]; undated.Title != "Undated" || !undated.FirstAddedAt.IsZero() {
+		t.Errorf("expected undated book without dates, got %+v", undated)
+	}
+}
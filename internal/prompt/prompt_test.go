@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{" yes \n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+		{"y", true},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		got, err := Confirm(strings.NewReader(test.input), &out, "Delete?")
+		if err != nil {
+			t.Fatalf("Confirm(%q) failed: %v", test.input, err)
+		}
+		if got != test.expected {
+			t.Errorf("Confirm(%q) = %v, expected %v", test.input, got, test.expected)
+		}
+		if !strings.HasPrefix(out.String(), "Delete? [y/N]: ") {
+			t.Errorf("Unexpected prompt %q", out.String())
+		}
+	}
+}
+
+func TestSecretRequiresTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if IsTerminal(r) {
+		t.Fatal("expected a pipe not to be a terminal")
+	}
+	var out bytes.Buffer
+	if _, err := Secret(context.Background(), r, &out, "Token"); !errors.Is(err, ErrNotTerminal) {
+		t.Errorf("expected ErrNotTerminal, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no prompt, got %q", out.String())
+	}
+}
@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrNotTerminal is returned by Secret when input does not come from a terminal
+var ErrNotTerminal = errors.New("input is not a terminal")
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	return isTerminal(f.Fd())
+}
+
+// Secret asks for a value on out and reads it from the terminal in without
+// echoing it. The terminal is restored when ctx is cancelled, e.g. by Ctrl-C.
+func Secret(ctx context.Context, in *os.File, out io.Writer, question string) (string, error) {
+	fd := in.Fd()
+	if !isTerminal(fd) {
+		return "", ErrNotTerminal
+	}
+
+	fmt.Fprintf(out, "%s: ", question)
+	restore, err := disableEcho(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to hide input: %w", err)
+	}
+	defer func() {
+		restore()
+		// The newline typed by the user was not echoed
+		fmt.Fprintln(out)
+	}()
+
+	type result struct {
+		line string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		done <- result{line, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-done:
+		if r.err != nil && r.err != io.EOF {
+			return "", fmt.Errorf("failed to read answer: %w", r.err)
+		}
+		return strings.TrimRight(r.line, "\r\n"), nil
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows)
+
+package prompt
+
+import "errors"
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func disableEcho(fd uintptr) (func() error, error) {
+	return nil, errors.New("hiding input is not supported on this platform")
+}
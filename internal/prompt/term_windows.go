@@ -0,0 +1,30 @@
+package prompt
+
+import (
+	"syscall"
+)
+
+const enableEchoInput = 0x0004
+
+var setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+func isTerminal(fd uintptr) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(fd), &mode) == nil
+}
+
+func disableEcho(fd uintptr) (func() error, error) {
+	var mode uint32
+	if err := syscall.GetConsoleMode(syscall.Handle(fd), &mode); err != nil {
+		return nil, err
+	}
+	if r, _, err := setConsoleMode.Call(fd, uintptr(mode&^enableEchoInput)); r == 0 {
+		return nil, err
+	}
+	return func() error {
+		if r, _, err := setConsoleMode.Call(fd, uintptr(mode)); r == 0 {
+			return err
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,101 @@
+package reconcile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+// Change pairs a local clipping with the server clipping it replaces
+type Change struct {
+	Local  models.ClippingItem `json:"local"`
+	Server models.ClippingItem `json:"server"`
+}
+
+// Diff is the difference between local clippings and the server library
+type Diff struct {
+	// Added holds clippings only present locally
+	Added []models.ClippingItem `json:"added"`
+	// ServerOnly holds clippings only present on the server
+	ServerOnly []models.ClippingItem `json:"serverOnly"`
+	// Changed holds clippings at the same book and location whose content differs
+	Changed []Change `json:"changed"`
+	// Unchanged counts clippings present on both sides
+	Unchanged int `json:"unchanged"`
+}
+
+// Empty reports whether local and server clippings are in sync
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.ServerOnly) == 0 && len(d.Changed) == 0
+}
+
+// ContentHash returns a stable hash of a clipping's content, ignoring
+// differences in surrounding and repeated whitespace
+func ContentHash(item models.ClippingItem) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(item.Content), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// bookKey normalises a book title for matching
+func bookKey(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// Compare matches local clippings against server clippings by book and
+// content hash. Unmatched clippings of the same book and location are
+// reported as changed; the rest are added locally or present only on the
+// server. Duplicates are matched one to one.
+func Compare(local, server []models.ClippingItem) Diff {
+	var diff Diff
+
+	// Index server clippings by book and content hash
+	byContent := make(map[string][]int)
+	for i, item := range server {
+		key := bookKey(item.Title) + "\x00" + ContentHash(item)
+		byContent[key] = append(byContent[key], i)
+	}
+
+	matched := make([]bool, len(server))
+	var unmatched []models.ClippingItem
+	for _, item := range local {
+		key := bookKey(item.Title) + "\x00" + ContentHash(item)
+		if candidates := byContent[key]; len(candidates) > 0 {
+			matched[candidates[0]] = true
+			byContent[key] = candidates[1:]
+			diff.Unchanged++
+			continue
+		}
+		unmatched = append(unmatched, item)
+	}
+
+	// Remaining server clippings by book and location are candidates for edits
+	byLocation := make(map[string][]int)
+	for i, item := range server {
+		if matched[i] || item.PageAt == "" {
+			continue
+		}
+		key := bookKey(item.Title) + "\x00" + item.PageAt
+		byLocation[key] = append(byLocation[key], i)
+	}
+
+	for _, item := range unmatched {
+		key := bookKey(item.Title) + "\x00" + item.PageAt
+		if candidates := byLocation[key]; item.PageAt != "" && len(candidates) > 0 {
+			matched[candidates[0]] = true
+			byLocation[key] = candidates[1:]
+			diff.Changed = append(diff.Changed, Change{Local: item, Server: server[candidates[0]]})
+			continue
+		}
+		diff.Added = append(diff.Added, item)
+	}
+
+	for i, item := range server {
+		if !matched[i] {
+			diff.ServerOnly = append(diff.ServerOnly, item)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,59 @@
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+func clipping(title, pageAt, content string) models.ClippingItem {
+	return models.ClippingItem{Title: title, PageAt: pageAt, Content: content}
+}
+
+func TestCompare(t *testing.T) {
+	local := []models.ClippingItem{
+		clipping("Bad Blood", "#27", "the reader called a photomultiplier"),
+		clipping("Bad Blood", "#50", "Susan was mortified"),
+		clipping("Bad Blood", "#51", "a brand new highlight"),
+		clipping("深度工作", "#42-43", "专注力就像肌肉一样"),
+	}
+	server := []models.ClippingItem{
+		// Same clipping with different whitespace and title case
+		clipping("bad blood", "#27", "the reader  called a\nphotomultiplier"),
+		// Same location, typo fixed locally
+		clipping("Bad Blood", "#50", "Susan was mortifed"),
+		clipping("Pride and Prejudice", "#14", "Happiness in marriage"),
+		clipping("深度工作", "#42-43", "专注力就像肌肉一样"),
+	}
+
+	diff := Compare(local, server)
+
+	if diff.Unchanged != 2 {
+		t.Errorf("Expected 2 unchanged, got %d", diff.Unchanged)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].PageAt != "#51" {
+		t.Errorf("Unexpected added clippings: %+v", diff.Added)
+	}
+	if len(diff.ServerOnly) != 1 || diff.ServerOnly[0].Title != "Pride and Prejudice" {
+		t.Errorf("Unexpected server-only clippings: %+v", diff.ServerOnly)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Server.Content != "Susan was mortifed" || diff.Changed[0].Local.Content != "Susan was mortified" {
+		t.Errorf("Unexpected changed clippings: %+v", diff.Changed)
+	}
+	if diff.Empty() {
+		t.Error("Expected a non-empty diff")
+	}
+}
+
+func TestCompareDuplicates(t *testing.T) {
+	item := clipping("Book", "#1", "same")
+	diff := Compare([]models.ClippingItem{item, item}, []models.ClippingItem{item})
+
+	if diff.Unchanged != 1 || len(diff.Added) != 1 || len(diff.ServerOnly) != 0 {
+		t.Errorf("Expected duplicates to match one to one, got %+v", diff)
+	}
+
+	if !Compare([]models.ClippingItem{item}, []models.ClippingItem{item}).Empty() {
+		t.Error("Expected identical clippings to produce an empty diff")
+	}
+}
@@ -0,0 +1,208 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/clippingkk/cli/internal/library"
+	"github.com/clippingkk/cli/internal/models"
+)
+
+// Range is a match in the clipping content, as UTF-8 byte offsets
+type Range struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Result is a clipping matching a query
+type Result struct {
+	Clipping models.ClippingItem `json:"clipping"`
+	Matches  []Range             `json:"matches"`
+}
+
+// Options selects the clippings to search and how the query matches
+type Options struct {
+	// Query holds whitespace separated terms that must all occur in the
+	// content, or a regular expression when Regex is set. An empty query
+	// matches every clipping passing the filters.
+	Query         string
+	Regex         bool
+	CaseSensitive bool
+
+	// Title and Author match case-insensitive substrings
+	Title  string
+	Author string
+	// Kind keeps only clippings of this kind; clippings of unknown kind
+	// count as highlights
+	Kind string
+	// Filter bounds the creation date
+	Filter library.Filter
+}
+
+// Matcher finds query matches in clipping content
+type Matcher struct {
+	patterns []*regexp.Regexp
+}
+
+// Compile builds a matcher. Plain terms are matched as substrings, which
+// suits CJK text without word separators; letters match regardless of case
+// and of full-width forms (ａ/Ａ/a/A) unless caseSensitive is set.
+func Compile(query string, regex, caseSensitive bool) (*Matcher, error) {
+	flags := ""
+	if !caseSensitive {
+		flags = "(?i)"
+	}
+
+	if regex {
+		pattern, err := regexp.Compile(flags + query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return &Matcher{patterns: []*regexp.Regexp{pattern}}, nil
+	}
+
+	var patterns []*regexp.Regexp
+	for _, term := range strings.Fields(query) {
+		pattern, err := regexp.Compile(flags + termPattern(term, caseSensitive))
+		if err != nil {
+			return nil, fmt.Errorf("invalid search term %q: %w", term, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return &Matcher{patterns: patterns}, nil
+}
+
+// termPattern quotes a term, letting each ASCII or full-width letter and
+// digit also match its other width
+func termPattern(term string, caseSensitive bool) string {
+	if caseSensitive {
+		return regexp.QuoteMeta(term)
+	}
+
+	var b strings.Builder
+	for _, r := range term {
+		ascii, wide := r, r
+		switch {
+		case r >= '!' && r <= '~':
+			wide = r - '!' + '！'
+		case r >= '！' && r <= '～':
+			ascii = r - '！' + '!'
+		}
+		if ascii == wide {
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+		b.WriteString("(?:")
+		b.WriteString(regexp.QuoteMeta(string(ascii)))
+		b.WriteString("|")
+		b.WriteString(string(wide))
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// Find returns the sorted, merged matches of every term in text, or nil
+// when a term does not occur
+func (m *Matcher) Find(text string) []Range {
+	if len(m.patterns) == 0 {
+		return []Range{}
+	}
+
+	var ranges []Range
+	for _, pattern := range m.patterns {
+		found := pattern.FindAllStringIndex(text, -1)
+		if found == nil {
+			return nil
+		}
+		for _, loc := range found {
+			if loc[0] == loc[1] {
+				continue
+			}
+			ranges = append(ranges, Range{Start: loc[0], End: loc[1]})
+		}
+	}
+	return merge(ranges)
+}
+
+// merge sorts ranges and joins overlapping ones
+func merge(ranges []Range) []Range {
+	if len(ranges) == 0 {
+		return []Range{}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	merged := []Range{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// Search returns the clippings matching opts, in input order
+func Search(clippings []models.ClippingItem, opts Options) ([]Result, error) {
+	matcher, err := Compile(opts.Query, opts.Regex, opts.CaseSensitive)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	for _, item := range clippings {
+		if !opts.Filter.Match(item) || !matchKind(item, opts.Kind) {
+			continue
+		}
+		if !containsFold(item.Title, opts.Title) || !containsFold(item.Author, opts.Author) {
+			continue
+		}
+		matches := matcher.Find(item.Content)
+		if matches == nil {
+			continue
+		}
+		results = append(results, Result{Clipping: item, Matches: matches})
+	}
+	return results, nil
+}
+
+// Highlight wraps every match of text in before and after
+func Highlight(text string, matches []Range, before, after string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.Start < last || m.End > len(text) || !utf8.ValidString(text[m.Start:m.End]) {
+			continue
+		}
+		b.WriteString(text[last:m.Start])
+		b.WriteString(before)
+		b.WriteString(text[m.Start:m.End])
+		b.WriteString(after)
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func matchKind(item models.ClippingItem, kind string) bool {
+	if kind == "" {
+		return true
+	}
+	itemKind := item.Kind
+	if itemKind == "" {
+		itemKind = models.KindHighlight
+	}
+	return strings.EqualFold(itemKind, kind)
+}
+
+func containsFold(s, substr string) bool {
+	return substr == "" || strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
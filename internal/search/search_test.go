@@ -0,0 +1,91 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/internal/library"
+	"github.com/clippingkk/cli/internal/models"
+)
+
+func TestMatcherFind(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		regex    bool
+		text     string
+		expected []Range
+	}{
+		{"case folding", "susan", false, "Susan was mortified", []Range{{0, 5}}},
+		{"all terms required", "susan elizabeth", false, "Susan was mortified", nil},
+		{"terms merged in order", "was susan", false, "Susan was mortified", []Range{{0, 5}, {6, 9}}},
+		{"CJK substring", "宇宙", false, "弱小和无知不是生存的障碍，傲慢才是。宇宙就是一座黑暗森林", []Range{{54, 60}}},
+		{"full width letters", "ai", false, "研究ＡＩ的人", []Range{{6, 12}}},
+		{"regex", `mind-\w+`, true, "Fear is the mind-killer.", []Range{{12, 23}}},
+		{"empty query", "", false, "anything", []Range{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := Compile(test.query, test.regex, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := matcher.Find(test.text)
+			if (got == nil) != (test.expected == nil) || len(got) != len(test.expected) {
+				t.Fatalf("Find(%q) = %v, expected %v", test.text, got, test.expected)
+			}
+			for i := range got {
+				if got[i] != test.expected[i] {
+					t.Fatalf("Find(%q) = %v, expected %v", test.text, got, test.expected)
+				}
+			}
+		})
+	}
+
+	if _, err := Compile("(", true, false); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	clippings := []models.ClippingItem{
+		{Title: "Dune", Author: "Frank Herbert", Kind: models.KindHighlight, Content: "Fear is the mind-killer.", CreatedAt: day(1)},
+		{Title: "Dune", Author: "Frank Herbert", Kind: models.KindNote, Content: "fear again", CreatedAt: day(5)},
+		{Title: "Bad Blood", Author: "John Carreyrou", Content: "No fear here", CreatedAt: day(10)},
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected int
+	}{
+		{"query only", Options{Query: "fear"}, 3},
+		{"title", Options{Query: "fear", Title: "dun"}, 2},
+		{"author", Options{Query: "fear", Author: "carreyrou"}, 1},
+		{"kind note", Options{Query: "fear", Kind: "note"}, 1},
+		{"unknown kind counts as highlight", Options{Query: "fear", Kind: "highlight"}, 2},
+		{"date range", Options{Query: "fear", Filter: library.Filter{Since: day(2), Until: day(10)}}, 1},
+		{"case sensitive", Options{Query: "Fear", CaseSensitive: true}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Search(clippings, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != test.expected {
+				t.Fatalf("expected %d results, got %d", test.expected, len(results))
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("宇宙就是一座黑暗森林", []Range{{0, 6}, {18, 30}}, "[", "]")
+	if got != "[宇宙]就是一座[黑暗森林]" {
+		t.Errorf("unexpected highlight %q", got)
+	}
+}
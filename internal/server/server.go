@@ -0,0 +1,323 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/clippingkk/cli/internal/library"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/source"
+	"github.com/clippingkk/cli/pkg/ckk"
+)
+
+// DefaultMaxBodySize bounds uploaded clippings files
+const DefaultMaxBodySize = 64 << 20
+
+// Options configures the local API
+type Options struct {
+	// Library provides the clippings served by /books and /clippings and
+	// synced by an empty POST /sync; nil disables those endpoints
+	Library source.Source
+	// Sync pushes clippings to ClippingKK; nil disables POST /sync. A nil
+	// report means the server was already up to date.
+	Sync func(ctx context.Context, clippings []models.ClippingItem) (*ckk.SyncReport, error)
+	// MaxBodySize bounds request bodies, DefaultMaxBodySize when zero
+	MaxBodySize int64
+	// Token is the secret every request must send as "Authorization:
+	// Bearer <token>", see NewToken
+	Token string
+	// Hosts are Host header names accepted besides localhost and loopback
+	// addresses, such as the address the server listens on
+	Hosts []string
+	// Origins are browser origins allowed besides loopback ones, such as
+	// "chrome-extension://<id>"
+	Origins []string
+}
+
+// NewToken returns a random token for Options.Token
+func NewToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Server is the local HTTP API of ck-cli:
+//
+//	GET  /health      liveness check
+//	POST /parse       parse an uploaded clippings file into JSON
+//	GET  /books       books of the local library
+//	GET  /clippings   clippings of the local library, filtered by book, author, since and until
+//	POST /sync        sync the uploaded clippings, or the local library when the body is empty
+//
+// Every request must carry the bearer token of Options.Token. Requests to
+// another Host or from a foreign Origin are rejected so web pages cannot
+// reach the API through DNS rebinding, and POST bodies must be JSON, JSON
+// lines or a multipart form, which browsers cannot send cross-origin without
+// a preflight.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+}
+
+// New returns the API handler
+func New(opts Options) *Server {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("POST /parse", s.handleParse)
+	s.mux.HandleFunc("GET /books", s.handleBooks)
+	s.mux.HandleFunc("GET /clippings", s.handleClippings)
+	s.mux.HandleFunc("POST /sync", s.handleSync)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, err := s.check(r); err != nil {
+		writeError(w, status, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// check verifies the Host, Origin, token and content type of a request
+func (s *Server) check(r *http.Request) (int, error) {
+	if !s.allowedHost(r.Host) {
+		return http.StatusMisdirectedRequest, fmt.Errorf("host %q is not allowed", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && !s.allowedOrigin(origin) {
+		return http.StatusForbidden, fmt.Errorf("origin %q is not allowed", origin)
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || s.opts.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+		return http.StatusUnauthorized, errors.New("missing or invalid bearer token, see the output of ck-cli serve")
+	}
+
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/json", "application/x-ndjson", "application/jsonl", "multipart/form-data":
+		default:
+			return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %q: send JSON or a multipart form with a \"file\" field", mediaType)
+		}
+	}
+	return 0, nil
+}
+
+// allowedHost reports whether host, with an optional port, is a loopback
+// name or one of Options.Hosts
+func (s *Server) allowedHost(host string) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.Trim(host, "[]")
+	if isLoopback(host) {
+		return true
+	}
+	for _, allowed := range s.opts.Hosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedOrigin reports whether a browser origin is loopback or one of
+// Options.Origins
+func (s *Server) allowedOrigin(origin string) bool {
+	for _, allowed := range s.opts.Origins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return isLoopback(u.Hostname())
+}
+
+// isLoopback reports whether host is localhost or a loopback address
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// SyncResult is the response of POST /sync
+type SyncResult struct {
+	Clippings int             `json:"clippings"`
+	Report    *ckk.SyncReport `json:"report,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleParse(w http.ResponseWriter, r *http.Request) {
+	clippings, err := s.readClippings(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if clippings == nil {
+		clippings = []models.ClippingItem{}
+	}
+	writeJSON(w, http.StatusOK, clippings)
+}
+
+func (s *Server) handleBooks(w http.ResponseWriter, r *http.Request) {
+	clippings, ok := s.loadLibrary(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, library.Books(clippings))
+}
+
+func (s *Server) handleClippings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := library.Filter{Book: query.Get("book"), Author: query.Get("author")}
+	for name, bound := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %w", name, err))
+			return
+		}
+		*bound = t
+	}
+
+	clippings, ok := s.loadLibrary(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, filter.Select(clippings))
+}
+
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Sync == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("sync is disabled"))
+		return
+	}
+
+	clippings, err := s.readClippings(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if clippings == nil {
+		var ok bool
+		if clippings, ok = s.loadLibrary(w, r); !ok {
+			return
+		}
+	}
+
+	report, err := s.opts.Sync(r.Context(), clippings)
+	result := SyncResult{Clippings: len(clippings), Report: report}
+	if err != nil {
+		result.Error = err.Error()
+		writeJSON(w, http.StatusBadGateway, result)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// readClippings decodes the clippings of a request body, sent as is or as
+// the "file" field of a multipart form. The format comes from the "format"
+// query parameter or the content type, else it is sniffed. An empty body
+// yields nil.
+func (s *Server) readClippings(w http.ResponseWriter, r *http.Request) ([]models.ClippingItem, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxBodySize)
+
+	format := r.URL.Query().Get("format")
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var body io.Reader = r.Body
+	if mediaType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			switch {
+			case strings.HasSuffix(header.Filename, ".jsonl"), strings.HasSuffix(header.Filename, ".ndjson"):
+				format = source.FormatJSONL
+			case strings.HasSuffix(header.Filename, ".json"):
+				format = source.FormatJSON
+			}
+		}
+	} else if format == "" {
+		switch mediaType {
+		case "application/json":
+			format = source.FormatJSON
+		case "application/x-ndjson", "application/jsonl":
+			format = source.FormatJSONL
+		}
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+	return source.Decode(data, format)
+}
+
+// loadLibrary reads the local library, writing the error response on failure
+func (s *Server) loadLibrary(w http.ResponseWriter, r *http.Request) ([]models.ClippingItem, bool) {
+	if s.opts.Library == nil {
+		writeError(w, http.StatusNotFound, errors.New("no library configured, start the server with --library"))
+		return nil, false
+	}
+	clippings, err := s.opts.Library.Load(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to load library: %w", err))
+		return nil, false
+	}
+	return clippings, true
+}
+
+// parseTime accepts RFC3339 timestamps and YYYY-MM-DD dates
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/internal/library"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/source"
+	"github.com/clippingkk/cli/pkg/ckk"
+)
+
+const sample = `Bad Blood (John Carreyrou)
+- Your Highlight on page 10 | Location 140-141 | Added on Monday, January 2, 2023 3:04:05 PM
+
+Susan was mortified.
+==========
+Dune (Frank Herbert)
+- Your Highlight on page 3 | Location 40-41 | Added on Tuesday, February 7, 2023 9:00:00 AM
+
+Fear is the mind-killer.
+==========
+`
+
+func testLibrary() source.Source {
+	return source.Func(func(ctx context.Context) ([]models.ClippingItem, error) {
+		return source.Decode([]byte(sample), source.FormatText)
+	})
+}
+
+const testToken = "secret"
+
+// get sends an authorized GET request to the test server
+func get(t *testing.T, url string) (*http.Response, error) {
+	t.Helper()
+	return send(t, http.MethodGet, url, "", nil)
+}
+
+// post sends an authorized POST request to the test server
+func post(t *testing.T, url, contentType string, body io.Reader) (*http.Response, error) {
+	t.Helper()
+	return send(t, http.MethodPost, url, contentType, body)
+}
+
+func send(t *testing.T, method, url, contentType string, body io.Reader) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func decode(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	ts := httptest.NewServer(New(Options{Token: testToken}))
+	defer ts.Close()
+
+	// Multipart upload as sent by browsers
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "My Clippings.txt")
+	part.Write([]byte(sample))
+	form.Close()
+
+	resp, err := post(t, ts.URL+"/parse", form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clippings []models.ClippingItem
+	decode(t, resp, &clippings)
+	if resp.StatusCode != http.StatusOK || len(clippings) != 2 {
+		t.Fatalf("expected 2 clippings from multipart upload, got %d (HTTP %d)", len(clippings), resp.StatusCode)
+	}
+	if clippings[0].Title != "Bad Blood" || clippings[0].Author != "John Carreyrou" {
+		t.Errorf("unexpected first clipping %+v", clippings[0])
+	}
+
+	resp, err = post(t, ts.URL+"/parse", "application/json", bytes.NewBufferString("[{"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected HTTP 400 for invalid JSON, got %d", resp.StatusCode)
+	}
+}
+
+func TestLibraryEndpoints(t *testing.T) {
+	ts := httptest.NewServer(New(Options{Library: testLibrary(), Token: testToken}))
+	defer ts.Close()
+
+	resp, err := get(t, ts.URL+"/books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var books []library.Book
+	decode(t, resp, &books)
+	if len(books) != 2 || books[0].Title != "Dune" || books[0].Clippings != 1 {
+		t.Fatalf("unexpected books %+v", books)
+	}
+
+	resp, err = get(t, ts.URL+"/clippings?book=bad%20blood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clippings []models.ClippingItem
+	decode(t, resp, &clippings)
+	if len(clippings) != 1 || clippings[0].Title != "Bad Blood" {
+		t.Fatalf("unexpected clippings %+v", clippings)
+	}
+
+	resp, err = get(t, ts.URL+"/clippings?since=2023-02-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clippings = nil
+	decode(t, resp, &clippings)
+	if len(clippings) != 1 || clippings[0].Title != "Dune" {
+		t.Fatalf("unexpected clippings since February %+v", clippings)
+	}
+
+	resp, err = get(t, ts.URL+"/clippings?since=yesterday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected HTTP 400 for an invalid date, got %d", resp.StatusCode)
+	}
+
+	// Without a library the endpoints explain how to configure one
+	bare := httptest.NewServer(New(Options{Token: testToken}))
+	defer bare.Close()
+	resp, err = get(t, bare.URL+"/books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected HTTP 404 without library, got %d", resp.StatusCode)
+	}
+}
+
+func TestSync(t *testing.T) {
+	var synced []models.ClippingItem
+	ts := httptest.NewServer(New(Options{
+		Library: testLibrary(),
+		Token:   testToken,
+		Sync: func(ctx context.Context, clippings []models.ClippingItem) (*ckk.SyncReport, error) {
+			synced = clippings
+			return &ckk.SyncReport{Total: len(clippings), Succeeded: len(clippings)}, nil
+		},
+	}))
+	defer ts.Close()
+
+	// An empty body syncs the library
+	resp, err := post(t, ts.URL+"/sync", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result SyncResult
+	decode(t, resp, &result)
+	if resp.StatusCode != http.StatusOK || result.Clippings != 2 || len(synced) != 2 {
+		t.Fatalf("unexpected sync result %+v (HTTP %d)", result, resp.StatusCode)
+	}
+	if result.Report == nil || result.Report.Succeeded != 2 {
+		t.Errorf("expected the sync report in the response, got %+v", result.Report)
+	}
+
+	item := models.ClippingItem{Title: "Dune", Content: "Uploaded", PageAt: "1", CreatedAt: time.Now()}
+	payload, _ := json.Marshal([]models.ClippingItem{item})
+	resp, err = post(t, ts.URL+"/sync", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(synced) != 1 || synced[0].Content != "Uploaded" {
+		t.Fatalf("expected the posted clipping to be synced, got %+v", synced)
+	}
+
+	disabled := httptest.NewServer(New(Options{Library: testLibrary(), Token: testToken}))
+	defer disabled.Close()
+	resp, err = post(t, disabled.URL+"/sync", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Errorf("expected HTTP 501 with sync disabled, got %d", resp.StatusCode)
+	}
+}
+
+func TestRequestChecks(t *testing.T) {
+	ts := httptest.NewServer(New(Options{Token: testToken, Origins: []string{"chrome-extension://abc"}}))
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		token       string
+		host        string
+		origin      string
+		contentType string
+		status      int
+	}{
+		{name: "no token", status: http.StatusUnauthorized},
+		{name: "wrong token", token: "guess", status: http.StatusUnauthorized},
+		{name: "token", token: testToken, status: http.StatusOK},
+		{name: "rebound host", token: testToken, host: "evil.example:8723", status: http.StatusMisdirectedRequest},
+		{name: "localhost", token: testToken, host: "localhost:8723", status: http.StatusOK},
+		{name: "foreign origin", token: testToken, origin: "https://evil.example", status: http.StatusForbidden},
+		{name: "loopback origin", token: testToken, origin: "http://localhost:3000", status: http.StatusOK},
+		{name: "allowed origin", token: testToken, origin: "chrome-extension://abc", status: http.StatusOK},
+		{name: "text post", token: testToken, contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{name: "form post", token: testToken, contentType: "application/x-www-form-urlencoded", status: http.StatusUnsupportedMediaType},
+		{name: "JSON post", token: testToken, contentType: "application/json", status: http.StatusOK},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/health", nil)
+		if test.contentType != "" {
+			req, _ = http.NewRequest(http.MethodPost, ts.URL+"/parse", strings.NewReader("[]"))
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		if test.host != "" {
+			req.Host = test.host
+		}
+		if test.origin != "" {
+			req.Header.Set("Origin", test.origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%s: expected HTTP %d, got %d", test.name, test.status, resp.StatusCode)
+		}
+	}
+}
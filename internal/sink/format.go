@@ -0,0 +1,120 @@
+package sink
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+// Supported output formats for clippings
+const (
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+// Formats lists the supported formats, used in flag help and validation
+var Formats = []string{FormatJSON, FormatJSONL, FormatCSV, FormatMarkdown}
+
+// ResolveFormat returns the explicit format, or guesses it from the file extension
+func ResolveFormat(format, path string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jsonl", ".ndjson":
+			return FormatJSONL, nil
+		case ".csv":
+			return FormatCSV, nil
+		case ".md", ".markdown":
+			return FormatMarkdown, nil
+		default:
+			return FormatJSON, nil
+		}
+	}
+
+	for _, supported := range Formats {
+		if format == supported {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q: must be one of %s", format, strings.Join(Formats, ", "))
+}
+
+// Encode writes clippings to the writer in the given format
+func Encode(w io.Writer, format string, clippings []models.ClippingItem) error {
+	switch format {
+	case FormatJSON, "":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(clippings); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case FormatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, item := range clippings {
+			if err := encoder.Encode(item); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+		}
+		return nil
+	case FormatCSV:
+		return writeCSV(w, clippings)
+	case FormatMarkdown:
+		return writeMarkdown(w, clippings)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// writeCSV writes one row per clipping with a header row
+func writeCSV(w io.Writer, clippings []models.ClippingItem) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id", "title", "author", "content", "pageAt", "createdAt"}); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	for _, item := range clippings {
+		id := ""
+		if item.ID != 0 {
+			id = strconv.FormatInt(item.ID, 10)
+		}
+		record := []string{id, item.Title, item.Author, item.Content, item.PageAt, item.CreatedAt.UTC().Format(time.RFC3339)}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeMarkdown groups clippings by book as quoted blocks
+func writeMarkdown(w io.Writer, clippings []models.ClippingItem) error {
+	var current string
+	for i, item := range clippings {
+		if i == 0 || item.Title != current {
+			current = item.Title
+			heading := "## " + item.Title
+			if item.Author != "" {
+				heading += " — " + item.Author
+			}
+			if _, err := fmt.Fprintf(w, "%s\n\n", heading); err != nil {
+				return err
+			}
+		}
+
+		quoted := "> " + strings.ReplaceAll(item.Content, "\n", "\n> ")
+		if _, err := fmt.Fprintf(w, "%s\n\n— %s, %s\n\n", quoted, item.PageAt, item.CreatedAt.UTC().Format("2006-01-02")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
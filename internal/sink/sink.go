@@ -0,0 +1,168 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/uri"
+)
+
+// Sink is an output destination for parsed clippings
+type Sink interface {
+	// Write delivers the complete set of clippings to the destination
+	Write(ctx context.Context, clippings []models.ClippingItem) error
+	// Close releases any resources held by the sink
+	Close() error
+}
+
+// Target is a parsed output URI such as "file:out.json?format=csv"
+type Target = uri.Target
+
+// Factory opens a sink for a target of the scheme it is registered for
+type Factory func(target Target) (Sink, error)
+
+// Registry maps URI schemes to sink factories
+type Registry struct {
+	// DefaultFormat applies to file and stdout sinks without a format option
+	DefaultFormat string
+
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry returns a registry with the built-in "stdout:", "file:" and
+// "sqlite:" sinks
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]Factory)}
+	r.Register("stdout", r.openStdout)
+	r.Register("file", r.openFile)
+	r.Register("sqlite", openSQLite)
+	return r
+}
+
+// Register adds or replaces the factory for a URI scheme
+func (r *Registry) Register(scheme string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[strings.ToLower(scheme)] = factory
+}
+
+// Schemes returns the registered URI schemes in sorted order
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schemes := make([]string, 0, len(r.factories))
+	for scheme := range r.factories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// ParseTarget splits an output URI into scheme, path and query. Values
+// without a registered scheme are file paths, "" and "-" mean stdout.
+func (r *Registry) ParseTarget(raw string) (Target, error) {
+	target, err := uri.Parse(raw, func(scheme string) bool {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		_, ok := r.factories[scheme]
+		return ok
+	})
+	if err != nil {
+		return Target{}, fmt.Errorf("%w (supported outputs: %s)", err, strings.Join(r.Schemes(), ", "))
+	}
+	if target.Scheme == "stdio" {
+		target.Scheme = "stdout"
+	}
+	return target, nil
+}
+
+// Open parses target and opens a sink through the factory of its scheme
+func (r *Registry) Open(raw string) (Sink, error) {
+	target, err := r.ParseTarget(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	factory := r.factories[target.Scheme]
+	r.mu.RUnlock()
+
+	return factory(target)
+}
+
+// openStdout creates a sink writing to standard output
+func (r *Registry) openStdout(target Target) (Sink, error) {
+	format, err := ResolveFormat(target.Format(r.DefaultFormat), "")
+	if err != nil {
+		return nil, err
+	}
+	return &stdoutSink{format: format}, nil
+}
+
+// openFile creates a sink writing to a file; "file:///abs/path" and
+// "file:rel/path" forms are both accepted
+func (r *Registry) openFile(target Target) (Sink, error) {
+	path := target.FilePath()
+	if path == "" {
+		return nil, fmt.Errorf("missing file path in %q", target.Raw)
+	}
+
+	format, err := ResolveFormat(target.Format(r.DefaultFormat), path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{path: path, format: format}, nil
+}
+
+type stdoutSink struct {
+	format string
+}
+
+func (s *stdoutSink) Write(ctx context.Context, clippings []models.ClippingItem) error {
+	return Encode(os.Stdout, s.format, clippings)
+}
+
+func (s *stdoutSink) Close() error { return nil }
+
+type fileSink struct {
+	path   string
+	format string
+}
+
+func (s *fileSink) Write(ctx context.Context, clippings []models.ClippingItem) error {
+	file, err := os.Create(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	if err := Encode(file, s.format, clippings); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "💾 Saved %d clippings to %s\n", len(clippings), s.path)
+	return nil
+}
+
+func (s *fileSink) Close() error { return nil }
+
+// Func adapts a function to a Sink without resources to release
+type Func func(ctx context.Context, clippings []models.ClippingItem) error
+
+// Write calls f
+func (f Func) Write(ctx context.Context, clippings []models.ClippingItem) error {
+	return f(ctx, clippings)
+}
+
+// Close does nothing
+func (f Func) Close() error { return nil }
@@ -0,0 +1,150 @@
+package sink
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+func TestParseTarget(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("https", func(target Target) (Sink, error) { return nil, nil })
+
+	tests := []struct {
+		raw    string
+		scheme string
+		path   string
+		format string
+	}{
+		{"", "stdout", "", ""},
+		{"-", "stdout", "", ""},
+		{"stdout:?format=csv", "stdout", "", "csv"},
+		{"http_backup.json", "file", "http_backup.json", ""},
+		{"file:out.jsonl", "file", "out.jsonl", ""},
+		{"file:///tmp/out.md?format=markdown", "file", "///tmp/out.md", "markdown"},
+		{`C:\clippings.json`, "file", `C:\clippings.json`, ""},
+		{"https://example.com/graphql", "https", "//example.com/graphql", ""},
+		{"sqlite:clippings.db", "sqlite", "clippings.db", ""},
+	}
+
+	for _, test := range tests {
+		target, err := registry.ParseTarget(test.raw)
+		if err != nil {
+			t.Errorf("ParseTarget(%q) failed: %v", test.raw, err)
+			continue
+		}
+		if target.Scheme != test.scheme || target.Path != test.path || target.Format("") != test.format {
+			t.Errorf("ParseTarget(%q) = %+v, expected scheme %q path %q format %q",
+				test.raw, target, test.scheme, test.path, test.format)
+		}
+	}
+
+	if _, err := registry.ParseTarget("mongodb://localhost/clippings"); err == nil {
+		t.Error("Expected an error for an unregistered scheme")
+	}
+}
+
+func TestFileSinkFormats(t *testing.T) {
+	clippings := []models.ClippingItem{{
+		Title:     "Bad Blood",
+		Author:    "Carreyrou, John",
+		Content:   "the reader called a photomultiplier",
+		PageAt:    "#27",
+		CreatedAt: time.Date(2019, 2, 19, 19, 32, 33, 0, time.UTC),
+	}}
+
+	dir := t.TempDir()
+	registry := NewRegistry()
+
+	for _, name := range []string{"out.json", "out.jsonl", "out.csv", "out.md"} {
+		path := filepath.Join(dir, name)
+		s, err := registry.Open("file:" + path)
+		if err != nil {
+			t.Fatalf("Open(%s) failed: %v", name, err)
+		}
+		if err := s.Write(context.Background(), clippings); err != nil {
+			t.Fatalf("Write(%s) failed: %v", name, err)
+		}
+		s.Close()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "photomultiplier") {
+			t.Errorf("%s does not contain the clipping: %s", name, data)
+		}
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "out.csv"))
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil || len(records) != 2 || records[1][2] != "Carreyrou, John" {
+		t.Errorf("Unexpected CSV output %v: %v", records, err)
+	}
+}
+
+func TestRegistryCustomScheme(t *testing.T) {
+	var written []models.ClippingItem
+	registry := NewRegistry()
+	registry.Register("memory", func(target Target) (Sink, error) {
+		return Func(func(ctx context.Context, clippings []models.ClippingItem) error {
+			written = clippings
+			return nil
+		}), nil
+	})
+
+	s, err := registry.Open("memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := s.Write(context.Background(), make([]models.ClippingItem, 3)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if len(written) != 3 {
+		t.Errorf("Expected 3 clippings written, got %d", len(written))
+	}
+
+	if _, err := registry.Open("file:out.json?format=xml"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestSQLiteSink(t *testing.T) {
+	if _, err := exec.LookPath(SQLiteCommand); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	clippings := []models.ClippingItem{
+		{Title: "Bad Blood", Author: "Carreyrou, John", Content: "Elizabeth's vision", PageAt: "#27"},
+		{Title: "Bad Blood", Author: "Carreyrou, John", Content: "Susan was mortified", PageAt: "#50"},
+	}
+
+	path := filepath.Join(t.TempDir(), "clippings.db")
+	s, err := NewRegistry().Open("sqlite:" + path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	// Clippings written again are not duplicated
+	for range 2 {
+		if err := s.Write(context.Background(), clippings); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	out, err := exec.Command(SQLiteCommand, path, "SELECT count(*), max(content) FROM clippings").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "2|Susan was mortified" {
+		t.Errorf("Unexpected rows %q", got)
+	}
+	if got, _ := exec.Command(SQLiteCommand, path, "SELECT content FROM clippings WHERE page_at = '#27'").Output(); strings.TrimSpace(string(got)) != "Elizabeth's vision" {
+		t.Errorf("Expected the quote to survive, got %q", got)
+	}
+}
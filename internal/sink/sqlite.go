@@ -0,0 +1,89 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+// SQLiteCommand is the sqlite3 shell the "sqlite:" sink runs, looked up in PATH
+var SQLiteCommand = "sqlite3"
+
+// sqliteSchema creates the clippings table; the same clipping written
+// twice is stored once
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS clippings (
+  id INTEGER,
+  title TEXT NOT NULL,
+  author TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  content TEXT NOT NULL,
+  page_at TEXT NOT NULL,
+  created_at TEXT NOT NULL,
+  UNIQUE (title, content, page_at)
+);
+`
+
+// openSQLite creates a sink adding clippings to a SQLite database file;
+// "sqlite:///abs/path" and "sqlite:rel/path" forms are both accepted
+func openSQLite(target Target) (Sink, error) {
+	path := target.FilePath()
+	if path == "" {
+		return nil, fmt.Errorf("missing database path in %q", target.Raw)
+	}
+	command, err := exec.LookPath(SQLiteCommand)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite outputs need the %s command: %w", SQLiteCommand, err)
+	}
+	return &sqliteSink{path: path, command: command}, nil
+}
+
+type sqliteSink struct {
+	path    string
+	command string
+}
+
+// Write inserts clippings in one transaction, skipping those already stored
+func (s *sqliteSink) Write(ctx context.Context, clippings []models.ClippingItem) error {
+	var script strings.Builder
+	script.WriteString("BEGIN;\n")
+	script.WriteString(sqliteSchema)
+	for _, item := range clippings {
+		id := "NULL"
+		if item.ID != 0 {
+			id = fmt.Sprint(item.ID)
+		}
+		fmt.Fprintf(&script, "INSERT OR IGNORE INTO clippings VALUES (%s, %s, %s, %s, %s, %s, %s);\n",
+			id, sqlQuote(item.Title), sqlQuote(item.Author), sqlQuote(item.Kind), sqlQuote(item.Content),
+			sqlQuote(item.PageAt), sqlQuote(item.CreatedAt.UTC().Format(time.RFC3339)))
+	}
+	script.WriteString("COMMIT;\n")
+	script.WriteString("SELECT total_changes();\n")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.command, "-bail", s.path)
+	cmd.Stdin = strings.NewReader(script.String())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to write SQLite database %s: %w: %s", s.path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	added := strings.TrimSpace(stdout.String())
+	fmt.Fprintf(os.Stderr, "💾 Added %s of %d clippings to %s\n", added, len(clippings), s.path)
+	return nil
+}
+
+func (s *sqliteSink) Close() error { return nil }
+
+// sqlQuote formats value as an SQL string literal; NUL bytes, which the
+// sqlite3 shell cannot read, are dropped
+func sqlQuote(value string) string {
+	value = strings.ReplaceAll(value, "\x00", "")
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,357 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clippingkk/cli/internal/device"
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/internal/uri"
+	"github.com/clippingkk/cli/pkg/parser"
+)
+
+// Source is an input providing clippings
+type Source interface {
+	// Load reads and decodes every clipping of the source
+	Load(ctx context.Context) ([]models.ClippingItem, error)
+}
+
+// Target is a parsed input URI such as "dir:~/kindle-backups"
+type Target = uri.Target
+
+// Factory opens a source for a target of the scheme it is registered for
+type Factory func(target Target) (Source, error)
+
+// Input formats understood by the decoders
+const (
+	// FormatText is Kindle's "My Clippings.txt" format
+	FormatText  = "txt"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+)
+
+// HTTPTimeout bounds downloads by the http: and https: sources
+const HTTPTimeout = 60 * time.Second
+
+// Registry maps URI schemes to source factories
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry returns a registry with the built-in sources: "stdin:",
+// "file:", "dir:", "json:", "jsonl:", "http:", "https:" and "device:"
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]Factory)}
+	r.Register("stdin", openStdin)
+	r.Register("file", openFile)
+	r.Register("dir", openDir)
+	r.Register("json", openFormat(FormatJSON))
+	r.Register("jsonl", openFormat(FormatJSONL))
+	r.Register("http", openHTTP)
+	r.Register("https", openHTTP)
+	r.Register("device", openDevice)
+	return r
+}
+
+// Register adds or replaces the factory for a URI scheme
+func (r *Registry) Register(scheme string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[strings.ToLower(scheme)] = factory
+}
+
+// Schemes returns the registered URI schemes in sorted order
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schemes := make([]string, 0, len(r.factories))
+	for scheme := range r.factories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Open picks the source for an input URI. Plain paths are files, or
+// directories of clipping files; "" and "-" read stdin.
+func (r *Registry) Open(raw string) (Source, error) {
+	target, err := uri.Parse(raw, func(scheme string) bool {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		_, ok := r.factories[scheme]
+		return ok
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w (supported inputs: %s)", err, strings.Join(r.Schemes(), ", "))
+	}
+
+	switch target.Scheme {
+	case "stdio":
+		target.Scheme = "stdin"
+	case "file":
+		if info, err := os.Stat(target.FilePath()); err == nil && info.IsDir() {
+			target.Scheme = "dir"
+		}
+	}
+
+	r.mu.RLock()
+	factory := r.factories[target.Scheme]
+	r.mu.RUnlock()
+
+	return factory(target)
+}
+
+// Func adapts a function to a Source
+type Func func(ctx context.Context) ([]models.ClippingItem, error)
+
+// Load calls f
+func (f Func) Load(ctx context.Context) ([]models.ClippingItem, error) {
+	return f(ctx)
+}
+
+func openStdin(target Target) (Source, error) {
+	format := target.Format("")
+	return Func(func(ctx context.Context) ([]models.ClippingItem, error) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		return Decode(data, format)
+	}), nil
+}
+
+func openFile(target Target) (Source, error) {
+	path := target.FilePath()
+	if path == "" {
+		return nil, fmt.Errorf("missing file path in %q", target.Raw)
+	}
+	format := target.Format("")
+	if format == "" {
+		format = formatFromPath(path)
+	}
+	return Func(func(ctx context.Context) ([]models.ClippingItem, error) {
+		return loadFile(path, format)
+	}), nil
+}
+
+// openFormat returns a factory reading a file in a fixed format
+func openFormat(format string) Factory {
+	return func(target Target) (Source, error) {
+		path := target.FilePath()
+		if path == "" {
+			return nil, fmt.Errorf("missing file path in %q", target.Raw)
+		}
+		return Func(func(ctx context.Context) ([]models.ClippingItem, error) {
+			return loadFile(path, format)
+		}), nil
+	}
+}
+
+// openDir reads every .txt, .json and .jsonl file below a directory, in path order
+func openDir(target Target) (Source, error) {
+	root := target.FilePath()
+	if root == "" {
+		return nil, fmt.Errorf("missing directory in %q", target.Raw)
+	}
+	return Func(func(ctx context.Context) ([]models.ClippingItem, error) {
+		var clippings []models.ClippingItem
+		err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".txt", ".json", ".jsonl", ".ndjson":
+			default:
+				return nil
+			}
+
+			items, err := loadFile(path, formatFromPath(path))
+			if err != nil {
+				return err
+			}
+			clippings = append(clippings, items...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s: %w", root, err)
+		}
+		return clippings, nil
+	}), nil
+}
+
+func openHTTP(target Target) (Source, error) {
+	format := target.Format("")
+	return Func(func(ctx context.Context) ([]models.ClippingItem, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.Raw, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		client := &http.Client{Timeout: HTTPTimeout}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", target.Raw, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to download %s: HTTP %d", target.Raw, resp.StatusCode)
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", target.Raw, err)
+		}
+
+		if format == "" {
+			format = formatFromPath(req.URL.Path)
+		}
+		return Decode(data, format)
+	}), nil
+}
+
+// openDevice reads My Clippings.txt from a mounted Kindle, either the one at
+// the given mount point ("device:/media/me/Kindle") or the most recently
+// updated one found in the usual mount roots ("device:")
+func openDevice(target Target) (Source, error) {
+	mountPoint := target.FilePath()
+	return Func(func(ctx context.Context) ([]models.ClippingItem, error) {
+		var kindle device.Device
+		if mountPoint != "" {
+			var ok bool
+			if kindle, ok = device.Inspect(mountPoint); !ok {
+				return nil, fmt.Errorf("no Kindle clippings found at %s", mountPoint)
+			}
+		} else {
+			var err error
+			if kindle, err = device.Find(device.DefaultRoots()); err != nil {
+				return nil, err
+			}
+		}
+		return loadFile(kindle.ClippingsPath, FormatText)
+	}), nil
+}
+
+// loadFile reads and decodes a single file
+func loadFile(path, format string) ([]models.ClippingItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+
+	clippings, err := Decode(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return clippings, nil
+}
+
+// formatFromPath guesses the input format from a file extension; unknown
+// extensions are sniffed from the content by Decode
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".txt":
+		return FormatText
+	default:
+		return ""
+	}
+}
+
+// Decode converts raw input into clippings. With an empty format, data
+// starting with '[' or '{' is tried as JSON first, and parsed as Kindle
+// clippings text when it is not: a book title may start with a bracket.
+func Decode(data []byte, format string) ([]models.ClippingItem, error) {
+	if format == "" {
+		trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\ufeff")), " \t\r\n")
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("[")):
+			format = FormatJSON
+		case bytes.HasPrefix(trimmed, []byte("{")):
+			format = FormatJSONL
+		}
+		if format != "" {
+			if clippings, err := Decode(data, format); err == nil {
+				return clippings, nil
+			}
+		}
+		format = FormatText
+	}
+
+	switch format {
+	case FormatText:
+		return parser.Parse(string(data))
+	case FormatJSON:
+		var records []json.RawMessage
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON: %w", err)
+		}
+		clippings := make([]models.ClippingItem, 0, len(records))
+		for i, record := range records {
+			item, err := decodeRecord(record)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode clipping %d: %w", i, err)
+			}
+			clippings = append(clippings, item)
+		}
+		return clippings, nil
+	case FormatJSONL:
+		var clippings []models.ClippingItem
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			record := bytes.TrimSpace(scanner.Bytes())
+			if len(record) == 0 {
+				continue
+			}
+			item, err := decodeRecord(record)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode line %d: %w", line, err)
+			}
+			clippings = append(clippings, item)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read JSONL: %w", err)
+		}
+		return clippings, nil
+	default:
+		return nil, fmt.Errorf("unsupported input format %q", format)
+	}
+}
+
+// decodeRecord decodes an exported clipping, or the clipping of a sync
+// dead-letter record so rejected clippings can be fixed and re-submitted
+func decodeRecord(record json.RawMessage) (models.ClippingItem, error) {
+	var wrapped struct {
+		Clipping json.RawMessage `json:"clipping"`
+	}
+	if err := json.Unmarshal(record, &wrapped); err == nil && len(wrapped.Clipping) > 0 {
+		record = wrapped.Clipping
+	}
+
+	var item models.ClippingItem
+	err := json.Unmarshal(record, &item)
+	return item, err
+}
@@ -0,0 +1,117 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const kindleText = `Bad Blood (Carreyrou, John)
+- Your Highlight on page 27 | Location 419-419 | Added on Tuesday, February 19, 2019 7:32:33 PM
+
+the reader called a photomultiplier
+==========
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func load(t *testing.T, input string) int {
+	t.Helper()
+	src, err := NewRegistry().Open(input)
+	if err != nil {
+		t.Fatalf("Open(%q) failed: %v", input, err)
+	}
+	clippings, err := src.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load(%q) failed: %v", input, err)
+	}
+	for _, item := range clippings {
+		if strings.TrimPrefix(item.Title, "[Series] ") != "Bad Blood" || item.Content != "the reader called a photomultiplier" {
+			t.Errorf("Unexpected clipping from %q: %+v", input, item)
+		}
+	}
+	return len(clippings)
+}
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	exported := `[{"title":"Bad Blood","content":"the reader called a photomultiplier","pageAt":"#27","createdAt":"2019-02-19T19:32:33Z"}]`
+	deadLetter := `[{"index":3,"clipping":{"title":"Bad Blood","content":"the reader called a photomultiplier","pageAt":"#27","createdAt":"2019-02-19T19:32:33Z"},"message":"duplicate"}]`
+	lines := `{"title":"Bad Blood","content":"the reader called a photomultiplier","pageAt":"#27","createdAt":"2019-02-19T19:32:33Z"}
+{"title":"Bad Blood","content":"the reader called a photomultiplier","pageAt":"#27","createdAt":"2019-02-19T19:32:33Z"}
+`
+
+	writeFile(t, filepath.Join(dir, "My Clippings.txt"), kindleText)
+	writeFile(t, filepath.Join(dir, "export.json"), exported)
+	writeFile(t, filepath.Join(dir, "rejected.json"), deadLetter)
+	writeFile(t, filepath.Join(dir, "export.log"), lines)
+	// A Kindle export whose first title starts with a bracket
+	writeFile(t, filepath.Join(dir, "series"), "[Series] "+kindleText)
+	writeFile(t, filepath.Join(dir, "backups", "2019", "clippings.txt"), kindleText)
+	writeFile(t, filepath.Join(dir, "backups", "notes.pdf"), "ignored")
+
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{filepath.Join(dir, "My Clippings.txt"), 1},
+		{"file:" + filepath.Join(dir, "My Clippings.txt"), 1},
+		{filepath.Join(dir, "export.json"), 1},
+		{filepath.Join(dir, "rejected.json"), 1},
+		{"jsonl:" + filepath.Join(dir, "export.log"), 2},
+		// Unknown extension, sniffed as JSONL from its content
+		{filepath.Join(dir, "export.log"), 2},
+		// Starts like JSON but is Kindle text
+		{filepath.Join(dir, "series"), 1},
+		{filepath.Join(dir, "backups"), 1},
+		// export.log and notes.pdf are skipped by extension
+		{"dir:" + dir, 4},
+	}
+
+	for _, test := range tests {
+		if got := load(t, test.input); got != test.expected {
+			t.Errorf("Load(%q) returned %d clippings, expected %d", test.input, got, test.expected)
+		}
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(kindleText))
+	}))
+	defer server.Close()
+
+	if got := load(t, server.URL+"/My%20Clippings.txt"); got != 1 {
+		t.Errorf("Expected 1 clipping, got %d", got)
+	}
+
+	src, err := NewRegistry().Open(server.URL + "/missing.txt")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := src.Load(context.Background()); err == nil {
+		t.Error("Expected an error for HTTP 404")
+	}
+}
+
+func TestUnknownScheme(t *testing.T) {
+	if _, err := NewRegistry().Open("ftp://example.com/clippings.txt"); err == nil {
+		t.Error("Expected an error for an unknown scheme")
+	}
+}
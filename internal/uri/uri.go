@@ -0,0 +1,67 @@
+package uri
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Target is a parsed input or output URI such as "file:out.json?format=csv"
+type Target struct {
+	// Raw is the target as given by the user
+	Raw    string
+	Scheme string
+	// Path is everything after the scheme, without the query string
+	Path  string
+	Query url.Values
+}
+
+// Format returns the target's format option, or fallback when unset
+func (t Target) Format(fallback string) string {
+	if format := t.Query.Get("format"); format != "" {
+		return format
+	}
+	return fallback
+}
+
+// FilePath returns Path without the "//" of "file:///abs/path" forms
+func (t Target) FilePath() string {
+	return strings.TrimPrefix(t.Path, "//")
+}
+
+// schemePattern matches a URI scheme prefix; single letters are left out so
+// Windows drive letters are treated as file paths
+var schemePattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]+):`)
+
+// Parse splits raw into scheme, path and query. Values without a scheme
+// accepted by known are returned with the "file" scheme and "" or "-" with
+// the "stdio" scheme; the caller decides what those mean.
+func Parse(raw string, known func(scheme string) bool) (Target, error) {
+	if raw == "" || raw == "-" {
+		return Target{Raw: raw, Scheme: "stdio", Query: url.Values{}}, nil
+	}
+
+	match := schemePattern.FindStringSubmatch(raw)
+	if match == nil {
+		return Target{Raw: raw, Scheme: "file", Path: raw, Query: url.Values{}}, nil
+	}
+
+	scheme := strings.ToLower(match[1])
+	if !known(scheme) {
+		return Target{}, fmt.Errorf("unknown scheme %q in %q", scheme, raw)
+	}
+
+	rest := raw[len(match[0]):]
+	query := url.Values{}
+	if i := strings.Index(rest, "?"); i != -1 {
+		var err error
+		query, err = url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return Target{}, fmt.Errorf("invalid options in %q: %w", raw, err)
+		}
+		rest = rest[:i]
+	}
+
+	return Target{Raw: raw, Scheme: scheme, Path: rest, Query: query}, nil
+}
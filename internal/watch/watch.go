@@ -0,0 +1,303 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/pkg/parser"
+)
+
+const (
+	// DefaultInterval is how often the clippings file is polled
+	DefaultInterval = 2 * time.Second
+	// DefaultDebounce is how long the file must stay unchanged before it is read,
+	// so a Kindle writing several clippings in a row is handled in one sync
+	DefaultDebounce = 3 * time.Second
+	// HeadSize is the number of leading bytes fingerprinted to recognise
+	// a replaced file
+	HeadSize = 4096
+)
+
+// separator closes every entry of a Kindle clippings file
+var separator = []byte("==========")
+
+// State records how far a clippings file has been consumed
+type State struct {
+	Path string `json:"path"`
+	// Offset is the number of bytes already parsed and handled
+	Offset int64 `json:"offset"`
+	// Head is the SHA-256 of the first min(Offset, HeadSize) bytes
+	Head      string    `json:"head"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LoadState reads a saved state; a missing file yields the zero state
+func LoadState(path string) (State, error) {
+	var state State
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to decode watch state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// SaveState writes state to path, creating its directory
+func SaveState(path string, state State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create watch state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode watch state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	return nil
+}
+
+// StatePath returns the state file for a watch key under the user cache directory
+func StatePath(key string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(cacheDir, "ck-cli", "watch", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// Chunk is the result of reading the end of a clippings file
+type Chunk struct {
+	Clippings []models.ClippingItem
+	// State is the state to save once Clippings are handled
+	State State
+	// Reset reports that the file was truncated or replaced and read from the start
+	Reset bool
+	// FromStart reports that the file was read from its first byte, on the
+	// first read or after a reset, so Clippings may have been handled before
+	FromStart bool
+}
+
+// ReadAppended parses the complete clippings written to path after
+// state.Offset. A file shorter than the offset, or whose leading bytes no
+// longer match, was truncated or replaced and is read from the start.
+// A trailing entry without its "==========" separator is left for the
+// next read.
+func ReadAppended(path string, state State) (Chunk, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Chunk{}, fmt.Errorf("failed to open clippings file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return Chunk{}, fmt.Errorf("failed to stat clippings file: %w", err)
+	}
+
+	offset := state.Offset
+	if offset > info.Size() {
+		offset = 0
+	}
+	if offset > 0 {
+		head, err := headHash(file, offset)
+		if err != nil {
+			return Chunk{}, err
+		}
+		if head != state.Head {
+			offset = 0
+		}
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return Chunk{}, fmt.Errorf("failed to seek clippings file: %w", err)
+	}
+	appended, err := io.ReadAll(io.LimitReader(file, info.Size()-offset))
+	if err != nil {
+		return Chunk{}, fmt.Errorf("failed to read clippings file: %w", err)
+	}
+
+	complete := completeEntries(appended)
+	next := State{Path: path, Offset: offset + int64(len(complete)), UpdatedAt: time.Now()}
+	next.Head, err = headHash(file, next.Offset)
+	if err != nil {
+		return Chunk{}, err
+	}
+
+	clippings, err := parser.Parse(string(complete))
+	if err != nil {
+		return Chunk{}, fmt.Errorf("failed to parse clippings: %w", err)
+	}
+	return Chunk{Clippings: clippings, State: next, Reset: state.Offset > 0 && offset == 0, FromStart: offset == 0}, nil
+}
+
+// completeEntries trims data after the line of its last separator
+func completeEntries(data []byte) []byte {
+	end := bytes.LastIndex(data, separator)
+	if end < 0 {
+		return nil
+	}
+	end += len(separator)
+	newline := bytes.IndexByte(data[end:], '\n')
+	if newline < 0 {
+		// The separator line itself may still be being written
+		return data[:end]
+	}
+	return data[:end+newline+1]
+}
+
+// headHash fingerprints the first min(size, HeadSize) bytes of file
+func headHash(file *os.File, size int64) (string, error) {
+	if size > HeadSize {
+		size = HeadSize
+	}
+	head := make([]byte, size)
+	if _, err := file.ReadAt(head, 0); err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read clippings file: %w", err)
+	}
+	sum := sha256.Sum256(head)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Watcher polls a clippings file and hands new clippings to Handle
+type Watcher struct {
+	// Locate returns the clippings file to watch; an error means it is not
+	// available right now, e.g. the Kindle is unplugged
+	Locate func() (string, error)
+	// Handle receives the clippings appended since the last successful call,
+	// with fromStart set when they were read from the start of the file; on
+	// error the same clippings are offered again after the next debounce
+	Handle func(ctx context.Context, clippings []models.ClippingItem, fromStart bool) error
+	// StatePath persists the read offset between runs
+	StatePath string
+	Interval  time.Duration
+	Debounce  time.Duration
+	// Log receives progress messages; nil discards them
+	Log io.Writer
+}
+
+// Run polls until ctx is cancelled, then returns nil
+func (w *Watcher) Run(ctx context.Context) error {
+	interval, debounce := w.Interval, w.Debounce
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if debounce < 0 {
+		debounce = 0
+	}
+	log := w.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	state, err := LoadState(w.StatePath)
+	if err != nil {
+		return err
+	}
+
+	var (
+		present    bool
+		lastSize   int64 = -1
+		lastMod    time.Time
+		changedAt  time.Time
+		pending    bool
+		lastLocate string
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		path, err := w.Locate()
+		var info os.FileInfo
+		if err == nil {
+			info, err = os.Stat(path)
+		}
+
+		switch {
+		case err != nil:
+			if present || lastLocate == "" {
+				fmt.Fprintf(log, "⏳ Waiting for clippings: %v\n", err)
+			}
+			present, lastSize, pending = false, -1, false
+			lastLocate = err.Error()
+		default:
+			if !present {
+				fmt.Fprintf(log, "👀 Watching %s\n", path)
+			}
+			present, lastLocate = true, path
+
+			now := time.Now()
+			if info.Size() != lastSize || !info.ModTime().Equal(lastMod) {
+				lastSize, lastMod = info.Size(), info.ModTime()
+				changedAt, pending = now, true
+			}
+
+			if pending && now.Sub(changedAt) >= debounce {
+				next, err := w.process(ctx, path, state, log)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					fmt.Fprintf(log, "❌ %v\n", err)
+					changedAt = now
+				} else {
+					state, pending = next, false
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(log, "👋 Stopped watching\n")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// process reads, handles and records the clippings appended to path
+func (w *Watcher) process(ctx context.Context, path string, state State, log io.Writer) (State, error) {
+	chunk, err := ReadAppended(path, state)
+	if err != nil {
+		return state, err
+	}
+	clippings, next := chunk.Clippings, chunk.State
+	if chunk.Reset {
+		fmt.Fprintf(log, "🔄 %s was truncated or replaced, reading from the start\n", path)
+	}
+
+	if len(clippings) > 0 {
+		fmt.Fprintf(log, "📝 %d new clippings in %s\n", len(clippings), path)
+		if err := w.Handle(ctx, clippings, chunk.FromStart); err != nil {
+			return state, err
+		}
+	}
+
+	if next.Offset != state.Offset || next.Head != state.Head {
+		if err := SaveState(w.StatePath, next); err != nil {
+			return state, err
+		}
+	}
+	return next, nil
+}
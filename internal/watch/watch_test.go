@@ -0,0 +1,175 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/internal/models"
+)
+
+func entry(title, content string) string {
+	return title + " (Author)\n" +
+		"- Your Highlight on page 1 | Location 10-11 | Added on Monday, January 2, 2023 3:04:05 PM\n\n" +
+		content + "\n==========\n"
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+}
+
+func TestReadAppended(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "My Clippings.txt")
+	appendFile(t, path, entry("Book A", "first")+entry("Book A", "second"))
+
+	chunk, err := ReadAppended(path, State{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunk.Clippings) != 2 || chunk.Reset {
+		t.Fatalf("expected 2 clippings without reset, got %d (reset %v)", len(chunk.Clippings), chunk.Reset)
+	}
+	state := chunk.State
+
+	// A half-written entry waits for its separator
+	appendFile(t, path, "Book B (Author)\n- Your Highlight on page 2")
+	chunk, err = ReadAppended(path, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunk.Clippings) != 0 || chunk.State.Offset != state.Offset {
+		t.Fatalf("expected partial entry to be skipped, got %d clippings at offset %d", len(chunk.Clippings), chunk.State.Offset)
+	}
+
+	appendFile(t, path, " | Location 20-21 | Added on Monday, January 2, 2023 3:04:05 PM\n\nthird\n==========\n")
+	chunk, err = ReadAppended(path, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunk.Clippings) != 1 || chunk.Clippings[0].Content != "third" || chunk.Clippings[0].Title != "Book B" {
+		t.Fatalf("expected only the appended clipping, got %+v", chunk.Clippings)
+	}
+	state = chunk.State
+
+	// Truncation restarts from the beginning
+	if err := os.WriteFile(path, []byte(entry("Book C", "fresh")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chunk, err = ReadAppended(path, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chunk.Reset || len(chunk.Clippings) != 1 || chunk.Clippings[0].Title != "Book C" {
+		t.Fatalf("expected reset with 1 clipping, got reset %v, %+v", chunk.Reset, chunk.Clippings)
+	}
+	state = chunk.State
+
+	// A replaced file that is longer but starts differently also restarts
+	replaced := entry("Book D", "other") + entry("Book D", "more") + entry("Book D", "again")
+	if err := os.WriteFile(path, []byte(replaced), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chunk, err = ReadAppended(path, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chunk.Reset || len(chunk.Clippings) != 3 {
+		t.Fatalf("expected reset with 3 clippings, got reset %v, %d", chunk.Reset, len(chunk.Clippings))
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "My Clippings.txt")
+	statePath := filepath.Join(dir, "state", "watch.json")
+
+	var mu sync.Mutex
+	var handled []models.ClippingItem
+	var fromStarts []bool
+	failures := 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := &Watcher{
+		Locate: func() (string, error) {
+			if _, err := os.Stat(path); err != nil {
+				return "", err
+			}
+			return path, nil
+		},
+		Handle: func(ctx context.Context, clippings []models.ClippingItem, fromStart bool) error {
+			mu.Lock()
+			defer mu.Unlock()
+			// The first delivery fails and must be offered again
+			if failures > 0 {
+				failures--
+				return os.ErrDeadlineExceeded
+			}
+			handled = append(handled, clippings...)
+			fromStarts = append(fromStarts, fromStart)
+			return nil
+		},
+		StatePath: statePath,
+		Interval:  10 * time.Millisecond,
+		Debounce:  30 * time.Millisecond,
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- watcher.Run(ctx) }()
+
+	// The file appears after the watcher started
+	time.Sleep(30 * time.Millisecond)
+	appendFile(t, path, entry("Book A", "first"))
+	waitFor(t, func() bool { return countHandled(&mu, &handled) == 1 })
+
+	appendFile(t, path, entry("Book A", "second"))
+	waitFor(t, func() bool { return countHandled(&mu, &handled) == 2 })
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+
+	// Only the first delivery read the file from the start
+	if len(fromStarts) != 2 || !fromStarts[0] || fromStarts[1] {
+		t.Errorf("expected the first chunk only to be read from the start, got %v", fromStarts)
+	}
+
+	state, err := LoadState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	if state.Offset != info.Size() {
+		t.Fatalf("expected saved offset %d, got %d", info.Size(), state.Offset)
+	}
+}
+
+func countHandled(mu *sync.Mutex, handled *[]models.ClippingItem) int {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(*handled)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
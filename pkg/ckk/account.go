@@ -0,0 +1,79 @@
+package ckk
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Account is the ClippingKK user a token belongs to
+type Account struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// meResponse represents the response from the me query
+type meResponse struct {
+	Me *Account `json:"me"`
+}
+
+// Me returns the account of the configured token. It fails when the server
+// rejects the token.
+func (c *Client) Me(ctx context.Context) (*Account, error) {
+	data, err := Do[meResponse](ctx, c, meOperation, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account: %w", err)
+	}
+	if data.Me == nil {
+		return nil, fmt.Errorf("failed to fetch account: the server returned no user for this token")
+	}
+	return data.Me, nil
+}
+
+// TokenInfo is what can be read from a token without the server
+type TokenInfo struct {
+	// ExpiresAt is zero when the token does not expire or is opaque
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	Scopes    []string  `json:"scopes,omitempty"`
+}
+
+// Expired reports whether the token expired before now
+func (t TokenInfo) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// InspectToken reads the expiry and scopes of a JWT. ok is false for opaque
+// tokens, whose details only the server knows.
+func InspectToken(token string) (info TokenInfo, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return TokenInfo{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return TokenInfo{}, false
+	}
+
+	var claims struct {
+		Exp    json.Number     `json:"exp"`
+		Scope  string          `json:"scope"`
+		Scopes json.RawMessage `json:"scopes"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return TokenInfo{}, false
+	}
+
+	if exp, err := claims.Exp.Int64(); err == nil && exp > 0 {
+		info.ExpiresAt = time.Unix(exp, 0)
+	}
+	info.Scopes = strings.Fields(claims.Scope)
+	var scopes []string
+	if json.Unmarshal(claims.Scopes, &scopes) == nil {
+		info.Scopes = append(info.Scopes, scopes...)
+	}
+	return info, true
+}
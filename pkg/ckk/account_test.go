@@ -0,0 +1,53 @@
+package ckk
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "X-CLI test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"message":"invalid token"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"me":{"id":7,"name":"reader","email":"reader@example.com"}}}`))
+	}))
+	defer server.Close()
+
+	account, err := newTestClient(server.URL).Me(context.Background())
+	if err != nil {
+		t.Fatalf("Me failed: %v", err)
+	}
+	if account.ID != 7 || account.Name != "reader" {
+		t.Errorf("unexpected account %+v", account)
+	}
+
+	client := NewClient(server.URL, WithToken("wrong"))
+	if _, err := client.Me(context.Background()); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+}
+
+func TestInspectToken(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1900000000,"scope":"clippings:read clippings:write"}`))
+	info, ok := InspectToken("header." + payload + ".signature")
+	if !ok {
+		t.Fatal("expected a JWT to be inspected")
+	}
+	if !info.ExpiresAt.Equal(time.Unix(1900000000, 0)) || len(info.Scopes) != 2 || info.Scopes[1] != "clippings:write" {
+		t.Errorf("unexpected token info %+v", info)
+	}
+	if info.Expired(time.Unix(1800000000, 0)) || !info.Expired(time.Unix(1900000000, 0)) {
+		t.Error("unexpected expiry check")
+	}
+
+	if _, ok := InspectToken("opaque-token"); ok {
+		t.Error("expected an opaque token not to be inspected")
+	}
+}
@@ -0,0 +1,174 @@
+package ckk
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrTokenExpired is returned when a token has expired and cannot be refreshed
+var ErrTokenExpired = errors.New("the token has expired, log in again")
+
+// Auth authenticates the requests of a Client
+type Auth interface {
+	// Apply adds credentials to the request, refreshing them first when
+	// they are known to have expired
+	Apply(ctx context.Context, req *http.Request) error
+}
+
+// Refresher is implemented by Auth providers able to renew their
+// credentials. The client calls Refresh once when the server rejects a
+// request as unauthenticated, then sends the request again.
+type Refresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// WithAuth authenticates every request with auth
+func WithAuth(auth Auth) Option {
+	return func(c *Client) {
+		c.auth = auth
+	}
+}
+
+// headerAuth sends a fixed Authorization header
+type headerAuth struct {
+	value string
+	// token is checked for expiry before use when it is a JWT
+	token string
+}
+
+func (a headerAuth) Apply(ctx context.Context, req *http.Request) error {
+	if info, ok := InspectToken(a.token); ok && info.Expired(time.Now()) {
+		return fmt.Errorf("%w (expired on %s)", ErrTokenExpired, info.ExpiresAt.Format(time.RFC3339))
+	}
+	req.Header.Set("Authorization", a.value)
+	return nil
+}
+
+// TokenAuth authenticates with a ClippingKK API token, sent as
+// "Authorization: X-CLI <token>"
+func TokenAuth(token string) Auth {
+	return headerAuth{value: "X-CLI " + token, token: token}
+}
+
+// BearerAuth authenticates with a bearer token such as a JWT issued by a
+// proxy in front of the service. An expired JWT fails with ErrTokenExpired
+// before the request is sent.
+func BearerAuth(token string) Auth {
+	return headerAuth{value: "Bearer " + token, token: token}
+}
+
+// BasicAuth authenticates with a username and password
+func BasicAuth(username, password string) Auth {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return headerAuth{value: "Basic " + credentials}
+}
+
+// ClientCertAuth authenticates with a TLS client certificate. It adds no
+// header; NewClient presents the certificate during the TLS handshake.
+func ClientCertAuth(cert tls.Certificate) Auth {
+	return certAuth{cert: cert}
+}
+
+// LoadClientCertAuth reads a PEM certificate and key for ClientCertAuth
+func LoadClientCertAuth(certFile, keyFile string) (Auth, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	return ClientCertAuth(cert), nil
+}
+
+type certAuth struct {
+	cert tls.Certificate
+}
+
+func (certAuth) Apply(context.Context, *http.Request) error {
+	return nil
+}
+
+// MultiAuth applies several providers in order, e.g. a client certificate
+// and a token. Refresh renews every provider able to.
+func MultiAuth(auths ...Auth) Auth {
+	return multiAuth(auths)
+}
+
+type multiAuth []Auth
+
+func (m multiAuth) Apply(ctx context.Context, req *http.Request) error {
+	for _, auth := range m {
+		if err := auth.Apply(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiAuth) Refresh(ctx context.Context) error {
+	refreshed := false
+	for _, auth := range m {
+		if refresher, ok := auth.(Refresher); ok {
+			if err := refresher.Refresh(ctx); err != nil {
+				return err
+			}
+			refreshed = true
+		}
+	}
+	if !refreshed {
+		return errNothingToRefresh
+	}
+	return nil
+}
+
+// errNothingToRefresh is returned by MultiAuth without a Refresher, so the
+// client reports the original failure
+var errNothingToRefresh = errors.New("no credentials to refresh")
+
+// eachAuth calls fn with auth and every provider combined in it
+func eachAuth(auth Auth, fn func(Auth)) {
+	if m, ok := auth.(multiAuth); ok {
+		for _, inner := range m {
+			eachAuth(inner, fn)
+		}
+		return
+	}
+	fn(auth)
+}
+
+// clientCertificates returns the certificates the providers in auth present
+func clientCertificates(auth Auth) []tls.Certificate {
+	var certs []tls.Certificate
+	eachAuth(auth, func(a Auth) {
+		if cert, ok := a.(certAuth); ok {
+			certs = append(certs, cert.cert)
+		}
+	})
+	return certs
+}
+
+// shareHTTPClient lets the OAuth2 providers in auth without an HTTP client
+// use httpClient
+func shareHTTPClient(auth Auth, httpClient *http.Client) {
+	eachAuth(auth, func(a Auth) {
+		if oauth, ok := a.(*OAuth2Auth); ok {
+			oauth.mu.Lock()
+			if oauth.config.HTTPClient == nil {
+				oauth.config.HTTPClient = httpClient
+			}
+			oauth.mu.Unlock()
+		}
+	})
+}
+
+// isUnauthenticated reports whether the server rejected the credentials
+func isUnauthenticated(err error) bool {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+	return respErr.StatusCode == http.StatusUnauthorized || respErr.HasCode("UNAUTHENTICATED")
+}
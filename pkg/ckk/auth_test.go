@@ -0,0 +1,259 @@
+package ckk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testJWT returns an unsigned JWT expiring at exp
+func testJWT(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":` + big.NewInt(exp.Unix()).String() + `}`))
+	return "header." + payload + ".signature"
+}
+
+func TestHeaderAuth(t *testing.T) {
+	server := newFakeServer(t)
+	server.respond("me", http.StatusOK, `{"data":{"me":{"id":1,"name":"reader"}}}`)
+
+	valid := testJWT(time.Now().Add(time.Hour))
+	tests := []struct {
+		auth Auth
+		want string
+	}{
+		{TokenAuth("abc"), "X-CLI abc"},
+		{BearerAuth(valid), "Bearer " + valid},
+		{BasicAuth("reader", "s3cret"), "Basic " + base64.StdEncoding.EncodeToString([]byte("reader:s3cret"))},
+	}
+	for _, test := range tests {
+		client := NewClient(server.URL, WithHeaders(map[string]string{"Authorization": "ignored"}), WithAuth(test.auth))
+		if _, err := client.Me(context.Background()); err != nil {
+			t.Fatalf("Me failed: %v", err)
+		}
+		if got := server.lastRequest(t).Header.Get("Authorization"); got != test.want {
+			t.Errorf("Authorization = %q, expected %q", got, test.want)
+		}
+	}
+
+	expired := NewClient(server.URL, WithAuth(BearerAuth(testJWT(time.Now().Add(-time.Minute)))))
+	server.respond("me", http.StatusInternalServerError, `expired tokens must not be sent`)
+	if _, err := expired.Me(context.Background()); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+}
+
+// fakeAuthServer is an OAuth2 authorization server offering the device flow
+type fakeAuthServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	pending   int
+	issued    int
+	refreshes []string
+}
+
+func newFakeAuthServer(t *testing.T, pending int) *fakeAuthServer {
+	t.Helper()
+	f := &fakeAuthServer{pending: pending}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("client_id") != "ck-cli" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch r.URL.Path + " " + r.Form.Get("grant_type") {
+		case "/device ":
+			w.Write([]byte(`{"device_code":"dev-1","user_code":"ABCD-EFGH","verification_uri":"https://example.com/device","expires_in":600,"interval":1}`))
+		case "/token urn:ietf:params:oauth:grant-type:device_code":
+			if f.pending > 0 {
+				f.pending--
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"authorization_pending"}`))
+				return
+			}
+			f.issued++
+			w.Write([]byte(`{"access_token":"access-1","refresh_token":"refresh-1","token_type":"Bearer","expires_in":3600}`))
+		case "/token refresh_token":
+			f.refreshes = append(f.refreshes, r.Form.Get("refresh_token"))
+			if r.Form.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"access-2","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"unsupported_grant_type"}`))
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeAuthServer) config() OAuth2Config {
+	return OAuth2Config{ClientID: "ck-cli", DeviceAuthURL: f.URL + "/device", TokenURL: f.URL + "/token", Scopes: []string{"clippings"}}
+}
+
+func TestOAuth2DeviceFlow(t *testing.T) {
+	defer func(unit time.Duration) { pollUnit = unit }(pollUnit)
+	pollUnit = time.Millisecond
+
+	auth := newFakeAuthServer(t, 2)
+	config := auth.config()
+
+	code, err := config.AuthorizeDevice(context.Background())
+	if err != nil {
+		t.Fatalf("AuthorizeDevice failed: %v", err)
+	}
+	if code.UserCode != "ABCD-EFGH" || code.VerificationURI != "https://example.com/device" {
+		t.Errorf("Unexpected device code: %+v", code)
+	}
+
+	token, err := config.PollToken(context.Background(), code)
+	if err != nil {
+		t.Fatalf("PollToken failed: %v", err)
+	}
+	if token.AccessToken != "access-1" || token.RefreshToken != "refresh-1" || token.Expired(time.Now()) {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+	if auth.pending != 0 || auth.issued != 1 {
+		t.Errorf("Expected polling until the code was approved, %d pending", auth.pending)
+	}
+}
+
+func TestOAuth2AuthRefreshes(t *testing.T) {
+	auth := newFakeAuthServer(t, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-2" {
+			w.Write([]byte(`{"errors":[{"message":"unauthenticated","extensions":{"code":"UNAUTHENTICATED"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"me":{"id":1,"name":"reader"}}}`))
+	}))
+	defer server.Close()
+
+	// An expired access token is renewed before the request
+	var saved []OAuth2Token
+	onRefresh := func(token OAuth2Token) error {
+		saved = append(saved, token)
+		return nil
+	}
+	expired := OAuth2Token{AccessToken: "access-1", RefreshToken: "refresh-1", Expiry: time.Now().Add(-time.Minute)}
+	provider := NewOAuth2Auth(auth.config(), expired, onRefresh)
+	if _, err := NewClient(server.URL, WithAuth(provider)).Me(context.Background()); err != nil {
+		t.Fatalf("Me failed: %v", err)
+	}
+	if len(saved) != 1 || saved[0].AccessToken != "access-2" || saved[0].RefreshToken != "refresh-1" {
+		t.Errorf("Unexpected saved tokens: %+v", saved)
+	}
+
+	// A token the server rejects is renewed once and the request repeated
+	revoked := OAuth2Token{AccessToken: "revoked", RefreshToken: "refresh-1", Expiry: time.Now().Add(time.Hour)}
+	provider = NewOAuth2Auth(auth.config(), revoked, onRefresh)
+	if _, err := NewClient(server.URL, WithAuth(provider)).Me(context.Background()); err != nil {
+		t.Fatalf("Me failed after refresh: %v", err)
+	}
+	if provider.Token().AccessToken != "access-2" {
+		t.Errorf("Expected the refreshed token, got %+v", provider.Token())
+	}
+
+	// Without a valid refresh token the original failure is kept
+	stale := OAuth2Token{AccessToken: "revoked", RefreshToken: "stale"}
+	_, err := NewClient(server.URL, WithAuth(NewOAuth2Auth(auth.config(), stale, nil))).Me(context.Background())
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || !respErr.HasCode("UNAUTHENTICATED") {
+		t.Errorf("Expected the UNAUTHENTICATED error, got %v", err)
+	}
+	if got := strings.Join(auth.refreshes, ","); got != "refresh-1,refresh-1,stale" {
+		t.Errorf("Unexpected refreshes %s", got)
+	}
+}
+
+// testCertificate creates a self-signed certificate for TLS tests
+func testCertificate(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestClientCertAuth(t *testing.T) {
+	clientCert := testCertificate(t, "reader")
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCert.Leaf)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "reader" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"me":{"id":1,"name":"reader"}}}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	auth := MultiAuth(ClientCertAuth(clientCert), TokenAuth("abc"))
+	if _, err := NewClient(server.URL, WithHTTPClient(server.Client()), WithAuth(auth)).Me(context.Background()); err != nil {
+		t.Fatalf("Me with a client certificate failed: %v", err)
+	}
+
+	if _, err := NewClient(server.URL, WithHTTPClient(server.Client())).Me(context.Background()); err == nil {
+		t.Error("Expected the handshake to fail without a client certificate")
+	}
+	if transport := server.Client().Transport.(*http.Transport); len(transport.TLSClientConfig.Certificates) != 0 {
+		t.Error("Expected the given HTTP client to be left unchanged")
+	}
+}
+
+func TestOAuth2ErrorResponse(t *testing.T) {
+	auth := newFakeAuthServer(t, 0)
+	config := auth.config()
+	config.ClientID = "unknown"
+
+	_, err := config.AuthorizeDevice(context.Background())
+	var oauthErr *OAuth2Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_client" {
+		t.Errorf("Expected invalid_client, got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package ckk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clippingkk/cli/pkg/clippings"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Book represents a book known to the ClippingKK service
+type Book struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// searchBooksResponse represents the response from the searchBooks query
+type searchBooksResponse struct {
+	SearchBooks []Book `json:"searchBooks"`
+}
+
+// searchBooksVariables represents variables for the searchBooks query
+type searchBooksVariables struct {
+	Query string `json:"query"`
+}
+
+// BookNotFoundTTL is how long a title the service does not know is
+// remembered before it is searched again
+const BookNotFoundTTL = 24 * time.Hour
+
+// bookCacheEntry is a search result kept in the cache file; an empty ID
+// records that the search found no book
+type bookCacheEntry struct {
+	ID        string    `json:"id,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// BookResolver maps clipping titles to ClippingKK book IDs. Pinned mappings
+// from the mapping file win over the local cache, which wins over searching
+// the service. The cache is kept per endpoint, since book IDs of one server
+// mean nothing to another.
+type BookResolver struct {
+	client    *Client
+	pins      map[string]string
+	cachePath string
+
+	mu    sync.Mutex
+	cache map[string]map[string]bookCacheEntry
+	dirty bool
+}
+
+// NewBookResolver creates a resolver pinning titles from the TOML mapping
+// file and caching lookups in the JSON cache file; missing files are fine
+func NewBookResolver(client *Client, mappingPath, cachePath string) (*BookResolver, error) {
+	resolver := &BookResolver{
+		client:    client,
+		pins:      make(map[string]string),
+		cachePath: cachePath,
+		cache:     make(map[string]map[string]bookCacheEntry),
+	}
+
+	if err := resolver.loadPins(mappingPath); err != nil {
+		return nil, err
+	}
+	if err := resolver.loadCache(); err != nil {
+		return nil, err
+	}
+
+	return resolver, nil
+}
+
+// loadPins reads the user's title to book ID mapping file, if present
+func (r *BookResolver) loadPins(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read book mapping file: %w", err)
+	}
+
+	if err := toml.Unmarshal(data, &r.pins); err != nil {
+		return fmt.Errorf("failed to parse book mapping file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadCache reads previously resolved book IDs, if present
+func (r *BookResolver) loadCache() error {
+	data, err := os.ReadFile(r.cachePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read book cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.cache); err != nil {
+		// A corrupt or outdated cache only costs a few extra lookups
+		r.cache = make(map[string]map[string]bookCacheEntry)
+	}
+	return nil
+}
+
+// cached returns the cache entry of a title and author for the endpoint of
+// the client, unless it is an expired "not found" answer
+func (r *BookResolver) cached(title, author string) (bookCacheEntry, bool) {
+	entry, ok := r.cache[r.client.endpoint][bookCacheKey(title, author)]
+	if ok && entry.ID == "" && time.Since(entry.CheckedAt) >= BookNotFoundTTL {
+		return bookCacheEntry{}, false
+	}
+	return entry, ok
+}
+
+// remember caches the result of a search, an empty ID when nothing matched
+func (r *BookResolver) remember(title, author, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, ok := r.cache[r.client.endpoint]
+	if !ok {
+		entries = make(map[string]bookCacheEntry)
+		r.cache[r.client.endpoint] = entries
+	}
+	entries[bookCacheKey(title, author)] = bookCacheEntry{ID: id, CheckedAt: time.Now().UTC()}
+	r.dirty = true
+}
+
+// Save writes newly resolved book IDs to the cache file
+func (r *BookResolver) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal book cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.cachePath), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(r.cachePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write book cache: %w", err)
+	}
+
+	r.dirty = false
+	return nil
+}
+
+// Resolve returns the book ID for every distinct title among clippings.
+// Titles that cannot be resolved are left out, so the server falls back to
+// matching by title; lookup failures are returned alongside the partial result.
+func (r *BookResolver) Resolve(ctx context.Context, items []clippings.Clipping) (map[string]string, error) {
+	resolved := make(map[string]string)
+	var pending []clippings.Clipping
+
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if seen[item.Title] {
+			continue
+		}
+		seen[item.Title] = true
+
+		if id, ok := r.pins[item.Title]; ok {
+			resolved[item.Title] = id
+			continue
+		}
+		if entry, ok := r.cached(item.Title, item.Author); ok {
+			if entry.ID != "" {
+				resolved[item.Title] = entry.ID
+			}
+			continue
+		}
+		pending = append(pending, item)
+	}
+
+	semaphore := make(chan struct{}, MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for _, item := range pending {
+		wg.Add(1)
+		go func(item clippings.Clipping) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			book, err := r.search(ctx, item.Title, item.Author)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%q: %w", item.Title, err))
+				return
+			}
+			if book == nil {
+				r.remember(item.Title, item.Author, "")
+				return
+			}
+			resolved[item.Title] = book.ID
+			r.remember(item.Title, item.Author, book.ID)
+		}(item)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return resolved, fmt.Errorf("failed to resolve %d books, first error: %w", len(errs), errs[0])
+	}
+	return resolved, nil
+}
+
+// search queries the service and picks the result matching title and author
+func (r *BookResolver) search(ctx context.Context, title, author string) (*Book, error) {
+	data, err := Do[searchBooksResponse](ctx, r.client, searchBooksOperation,
+		searchBooksVariables{Query: strings.TrimSpace(title + " " + author)})
+	if err != nil {
+		return nil, err
+	}
+
+	return matchBook(data.SearchBooks, title, author), nil
+}
+
+// matchBook returns the result with the same title, preferring one whose
+// author also matches. Search results with a different title are ignored
+// rather than guessed.
+func matchBook(books []Book, title, author string) *Book {
+	var match *Book
+	for i := range books {
+		book := &books[i]
+		if !strings.EqualFold(strings.TrimSpace(book.Title), title) {
+			continue
+		}
+		if author == "" || strings.EqualFold(strings.TrimSpace(book.Author), author) {
+			return book
+		}
+		if match == nil {
+			match = book
+		}
+	}
+	return match
+}
+
+// bookCacheKey builds the cache key for a title and author pair
+func bookCacheKey(title, author string) string {
+	if author == "" {
+		return title
+	}
+	return title + " | " + author
+}
@@ -0,0 +1,150 @@
+package ckk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBookResolverPinsCacheAndSearch(t *testing.T) {
+	var mu sync.Mutex
+	var searches []string
+	var payload []clippingInput
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			OperationName string          `json:"operationName"`
+			Variables     json.RawMessage `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch req.OperationName {
+		case "searchBooks":
+			var vars searchBooksVariables
+			json.Unmarshal(req.Variables, &vars)
+			searches = append(searches, vars.Query)
+			w.Write([]byte(`{"data":{"searchBooks":[
+				{"id":"7","title":"Bad Blood","author":"Someone Else"},
+				{"id":"42","title":"Bad Blood","author":"Carreyrou, John"}
+			]}}`))
+		case "createClippings":
+			var vars createClippingsVariables
+			json.Unmarshal(req.Variables, &vars)
+			payload = append(payload, vars.Payload...)
+			w.Write([]byte(`{"data":{"createClippings":[]}}`))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	mappingFile := filepath.Join(dir, "books.toml")
+	if err := os.WriteFile(mappingFile, []byte(`"Pinned Book" = "1001"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cacheFile := filepath.Join(dir, "cache", "books.json")
+
+	client := newTestClient(server.URL)
+	resolver, err := NewBookResolver(client, mappingFile, cacheFile)
+	if err != nil {
+		t.Fatalf("NewBookResolver failed: %v", err)
+	}
+	client.SetBookResolver(resolver)
+
+	clippings := testClippings(3)
+	clippings[0].Title, clippings[0].Author = "Bad Blood", "Carreyrou, John"
+	clippings[1].Title = "Pinned Book"
+	clippings[2].Title = "Unknown Book"
+
+	if _, err := client.SyncToServer(context.Background(), clippings); err != nil {
+		t.Fatalf("SyncToServer failed: %v", err)
+	}
+
+	bookIDs := make(map[string]string)
+	for _, input := range payload {
+		bookIDs[input.Title] = input.BookID
+	}
+	expected := map[string]string{"Bad Blood": "42", "Pinned Book": "1001", "Unknown Book": "0"}
+	for title, id := range expected {
+		if bookIDs[title] != id {
+			t.Errorf("Expected book ID %s for %q, got %q", id, title, bookIDs[title])
+		}
+	}
+	if len(searches) != 2 {
+		t.Errorf("Expected searches for the two unpinned books, got %v", searches)
+	}
+
+	// A second resolver must answer from the cache without searching
+	searches = nil
+	cached, err := NewBookResolver(client, mappingFile, cacheFile)
+	if err != nil {
+		t.Fatalf("NewBookResolver failed: %v", err)
+	}
+	resolved, err := cached.Resolve(context.Background(), clippings)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	// Unknown Book is remembered as not found
+	if resolved["Bad Blood"] != "42" || len(searches) != 0 {
+		t.Errorf("Expected cached book ID, got %v after searches %v", resolved, searches)
+	}
+
+	// Book IDs of one endpoint are not used for another
+	other, err := NewBookResolver(newTestClient(server.URL+"/staging"), mappingFile, cacheFile)
+	if err != nil {
+		t.Fatalf("NewBookResolver failed: %v", err)
+	}
+	if _, err := other.Resolve(context.Background(), clippings[:1]); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if len(searches) != 1 {
+		t.Errorf("Expected a search for the other endpoint, got %v", searches)
+	}
+}
+
+func TestBookResolverRetriesExpiredNotFound(t *testing.T) {
+	var searches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches++
+		w.Write([]byte(`{"data":{"searchBooks":[]}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	checked := time.Now().Add(-BookNotFoundTTL - time.Minute)
+	cache, _ := json.Marshal(map[string]map[string]bookCacheEntry{
+		server.URL: {"Unknown Book": {CheckedAt: checked}},
+	})
+	cacheFile := filepath.Join(t.TempDir(), "books.json")
+	if err := os.WriteFile(cacheFile, cache, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver, err := NewBookResolver(client, "", cacheFile)
+	if err != nil {
+		t.Fatalf("NewBookResolver failed: %v", err)
+	}
+	items := testClippings(1)
+	items[0].Title = "Unknown Book"
+	if _, err := resolver.Resolve(context.Background(), items); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if searches != 1 {
+		t.Errorf("Expected the expired answer to be searched again, got %d searches", searches)
+	}
+	// The fresh answer is used until it expires in turn
+	if _, err := resolver.Resolve(context.Background(), items); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if searches != 1 {
+		t.Errorf("Expected the fresh answer to be cached, got %d searches", searches)
+	}
+}
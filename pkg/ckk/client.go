@@ -0,0 +1,389 @@
+package ckk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/clippingkk/cli/pkg/clippings"
+)
+
+const (
+	// DefaultEndpoint is the ClippingKK GraphQL endpoint
+	DefaultEndpoint = "https://clippingkk-api.annatarhe.com/api/v2/graphql"
+	// ChunkSize is the number of clippings to send per request
+	ChunkSize = 20
+	// MaxConcurrency is the maximum number of concurrent requests
+	MaxConcurrency = 10
+	// RequestTimeout is the timeout for individual HTTP requests
+	RequestTimeout = 30 * time.Second
+)
+
+// clippingResult is the clipping returned by a mutation
+type clippingResult struct {
+	ID int64 `json:"id"`
+}
+
+// createClippingsVariables represents variables for createClippings mutation
+type createClippingsVariables struct {
+	Payload []clippingInput `json:"payload"`
+	Visible bool            `json:"visible"`
+}
+
+// clippingInput represents the input format for GraphQL mutations
+type clippingInput struct {
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	BookID    string `json:"bookID"`
+	PageAt    string `json:"pageAt"`
+	CreatedAt string `json:"createdAt"`
+	Source    string `json:"source"`
+}
+
+// newClippingInput converts a clipping to the mutation input format
+func newClippingInput(c clippings.Clipping) clippingInput {
+	return clippingInput{
+		Title:     c.Title,
+		Content:   c.Content,
+		BookID:    "0", // Default book ID
+		PageAt:    c.PageAt,
+		CreatedAt: c.CreatedAt.UTC().Format(time.RFC3339),
+		Source:    "kindle",
+	}
+}
+
+// Client represents an HTTP client for ClippingKK API
+type Client struct {
+	httpClient *http.Client
+	endpoint   string
+	headers    map[string]string
+	progress   ProgressReporter
+	books      *BookResolver
+	visibility Visibility
+	auth       Auth
+	tls        TLSOptions
+	proxy      ProxyOptions
+	// oauth2Client talks to authorization servers, see OAuth2HTTPClient
+	oauth2Client *http.Client
+}
+
+// Visibility decides whether the clippings of a book are synced as public
+type Visibility interface {
+	Visible(title string) bool
+}
+
+// VisibilityFunc adapts a function to the Visibility interface
+type VisibilityFunc func(title string) bool
+
+// Visible calls f(title)
+func (f VisibilityFunc) Visible(title string) bool {
+	return f(title)
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests through httpClient instead of a client with
+// RequestTimeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeaders adds headers to every request
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		for name, value := range headers {
+			c.headers[name] = value
+		}
+	}
+}
+
+// WithToken authenticates requests with a ClippingKK API token, see TokenAuth
+func WithToken(token string) Option {
+	return WithAuth(TokenAuth(token))
+}
+
+// WithProgress reports upload progress to progress, see SetProgressReporter
+func WithProgress(progress ProgressReporter) Option {
+	return func(c *Client) {
+		c.SetProgressReporter(progress)
+	}
+}
+
+// WithVisibility chooses the visibility of synced clippings per book; without
+// it every clipping is public
+func WithVisibility(visibility Visibility) Option {
+	return func(c *Client) {
+		c.visibility = visibility
+	}
+}
+
+// NewClient creates a client for the GraphQL endpoint, DefaultEndpoint when
+// empty. Progress is not reported unless WithProgress is given. Client
+// certificates, TLS and proxy options are applied to a copy of the HTTP
+// client's transport; an OAuth2Auth without an HTTP client of its own talks
+// to the authorization server with OAuth2HTTPClient.
+func NewClient(endpoint string, opts ...Option) *Client {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	c := &Client{
+		httpClient: &http.Client{
+			Timeout: RequestTimeout,
+		},
+		endpoint: endpoint,
+		headers:  make(map[string]string),
+		progress: noopProgress{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	certs := clientCertificates(c.auth)
+	configure := func(tlsOptions TLSOptions) func(*http.Transport) {
+		return func(transport *http.Transport) {
+			tlsOptions.apply(transport)
+			c.proxy.apply(transport)
+			transport.TLSClientConfig.Certificates = append(transport.TLSClientConfig.Certificates, certs...)
+		}
+	}
+	base := c.httpClient
+	if len(certs) > 0 || !c.tls.isZero() || !c.proxy.isZero() {
+		c.httpClient = withTransport(base, configure(c.tls))
+	}
+	c.oauth2Client = c.httpClient
+	if len(c.tls.PinnedKeys) > 0 {
+		// The pins are those of the API server, not of authorization servers
+		unpinned := c.tls
+		unpinned.PinnedKeys = nil
+		c.oauth2Client = withTransport(base, configure(unpinned))
+	}
+	shareHTTPClient(c.auth, c.oauth2Client)
+	return c
+}
+
+// Endpoint returns the GraphQL endpoint of the client
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+// HTTPClient returns the HTTP client requests are sent with, including the
+// TLS and proxy settings of the options
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// OAuth2HTTPClient returns the HTTP client for OAuth2 authorization servers:
+// that of HTTPClient without the pinned keys, which only apply to the API
+// server
+func (c *Client) OAuth2HTTPClient() *http.Client {
+	return c.oauth2Client
+}
+
+// SetProgressReporter replaces the reporter receiving upload progress; nil silences progress
+func (c *Client) SetProgressReporter(progress ProgressReporter) {
+	if progress == nil {
+		progress = noopProgress{}
+	}
+	c.progress = progress
+}
+
+// SetBookResolver enables resolving book IDs before upload; nil sends BookID "0"
+func (c *Client) SetBookResolver(resolver *BookResolver) {
+	c.books = resolver
+}
+
+// SyncToServer uploads clippings to the ClippingKK server.
+// The returned report is always non-nil once the endpoint is valid and
+// describes every chunk; the error aggregates all chunk failures.
+func (c *Client) SyncToServer(ctx context.Context, items []clippings.Clipping) (*SyncReport, error) {
+	if c.endpoint == "" || c.endpoint == "http" {
+		return nil, fmt.Errorf("no valid endpoint configured")
+	}
+
+	report := &SyncReport{
+		Endpoint: c.endpoint,
+		Total:    len(items),
+	}
+
+	// Look up the book of every title so the server does not have to guess
+	var bookIDs map[string]string
+	if c.books != nil {
+		var err error
+		bookIDs, err = c.books.Resolve(ctx, items)
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%v, unresolved books are matched by title on the server", err))
+		}
+		if err := c.books.Save(); err != nil {
+			report.Warnings = append(report.Warnings, err.Error())
+		}
+	}
+
+	// Split clippings into chunks of equal visibility
+	chunks := chunkClippings(items, ChunkSize, c.visibility)
+	for i := range chunks {
+		chunks[i].bookIDs = bookIDs
+	}
+	report.Chunks = make([]ChunkReport, len(chunks))
+
+	// Create a semaphore to limit concurrency
+	semaphore := make(chan struct{}, MaxConcurrency)
+
+	// Use WaitGroup to wait for all goroutines
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	c.progress.SyncStarted(len(items), len(chunks))
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(chunkIndex int, chunk clippingChunk) {
+			defer wg.Done()
+
+			// Acquire semaphore
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			chunkReport := ChunkReport{
+				Index:           chunkIndex + 1,
+				Status:          ChunkStatusOK,
+				Visible:         chunk.visible,
+				ClippingIndexes: chunk.indexes,
+			}
+			if err := c.syncChunk(ctx, chunk, &chunkReport); err != nil {
+				chunkReport.Status = ChunkStatusFailed
+				chunkReport.Error = err.Error()
+			}
+
+			mu.Lock()
+			report.Chunks[chunkIndex] = chunkReport
+			switch chunkReport.Status {
+			case ChunkStatusFailed:
+				report.Failed += len(chunk.items)
+			default:
+				report.Succeeded += len(chunk.items) - len(chunkReport.Rejected)
+				report.Failed += len(chunkReport.Rejected)
+			}
+			c.progress.ChunkFinished(chunkReport, len(chunks))
+			mu.Unlock()
+		}(i, chunk)
+	}
+
+	wg.Wait()
+
+	c.progress.SyncFinished(report)
+	return report, report.Err()
+}
+
+// syncChunk uploads a chunk of clippings. When the server rejects individual
+// clippings, identified by the payload index in the error path, those are
+// recorded in report.Rejected and the valid remainder is uploaded once more.
+func (c *Client) syncChunk(ctx context.Context, chunk clippingChunk, report *ChunkReport) error {
+	err := c.uploadChunk(ctx, convertToClippingInputs(chunk.items, chunk.bookIDs), report.Visible, report)
+	if err == nil {
+		return nil
+	}
+
+	rejected, ok := rejectedPayloadIndexes(report.GraphQLErrors, len(chunk.items))
+	if !ok {
+		return err
+	}
+
+	var remaining []clippings.Clipping
+	for i, item := range chunk.items {
+		graphqlErr, isRejected := rejected[i]
+		if !isRejected {
+			remaining = append(remaining, item)
+			continue
+		}
+		report.Rejected = append(report.Rejected, RejectedClipping{
+			Index:      report.ClippingIndexes[i],
+			Clipping:   item,
+			Message:    graphqlErr.Message,
+			Extensions: graphqlErr.Extensions,
+		})
+	}
+
+	report.Status = ChunkStatusPartial
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	// Retry only the clippings the server did not complain about
+	report.Retried = true
+	retry := ChunkReport{}
+	if err := c.uploadChunk(ctx, convertToClippingInputs(remaining, chunk.bookIDs), report.Visible, &retry); err != nil {
+		report.HTTPStatus = retry.HTTPStatus
+		report.GraphQLErrors = append(report.GraphQLErrors, retry.GraphQLErrors...)
+		return fmt.Errorf("retry of %d remaining clippings failed: %w", len(remaining), err)
+	}
+	report.HTTPStatus = retry.HTTPStatus
+
+	return nil
+}
+
+// uploadChunk uploads a single chunk of clippings, recording the HTTP status
+// and any GraphQL errors into report
+func (c *Client) uploadChunk(ctx context.Context, chunk []clippingInput, visible bool, report *ChunkReport) error {
+	request := createClippingsOperation.request(createClippingsVariables{
+		Payload: chunk,
+		Visible: visible,
+	})
+
+	status, err := c.post(ctx, request, nil)
+	report.HTTPStatus = status
+	report.GraphQLErrors = nil
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		report.GraphQLErrors = respErr.Errors
+	}
+	return err
+}
+
+// clippingChunk is a batch of clippings uploaded with a single mutation
+type clippingChunk struct {
+	items   []clippings.Clipping
+	indexes []int
+	visible bool
+	bookIDs map[string]string
+}
+
+// chunkClippings splits clippings into chunks of at most chunkSize items.
+// Public and private clippings never share a chunk since the mutation takes
+// a single visibility; every chunk remembers the original input indexes.
+// A nil visibility makes every clipping public.
+func chunkClippings(items []clippings.Clipping, chunkSize int, visibility Visibility) []clippingChunk {
+	var chunks []clippingChunk
+	open := make(map[bool]int)
+
+	for i, item := range items {
+		visible := visibility == nil || visibility.Visible(item.Title)
+		idx, ok := open[visible]
+		if !ok || len(chunks[idx].items) == chunkSize {
+			chunks = append(chunks, clippingChunk{visible: visible})
+			idx = len(chunks) - 1
+			open[visible] = idx
+		}
+		chunks[idx].items = append(chunks[idx].items, item)
+		chunks[idx].indexes = append(chunks[idx].indexes, i)
+	}
+
+	return chunks
+}
+
+// convertToClippingInputs converts clippings to clippingInput values,
+// filling in resolved book IDs by title
+func convertToClippingInputs(items []clippings.Clipping, bookIDs map[string]string) []clippingInput {
+	inputs := make([]clippingInput, len(items))
+	for i, item := range items {
+		inputs[i] = newClippingInput(item)
+		if id, ok := bookIDs[item.Title]; ok {
+			inputs[i].BookID = id
+		}
+	}
+	return inputs
+}
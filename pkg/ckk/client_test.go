@@ -0,0 +1,236 @@
+package ckk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clippingkk/cli/pkg/clippings"
+)
+
+func testClippings(n int) []clippings.Clipping {
+	items := make([]clippings.Clipping, n)
+	for i := range items {
+		items[i] = clippings.Clipping{
+			Title:     "Test Book",
+			Content:   strings.Repeat("x", i+1),
+			PageAt:    "#1",
+			CreatedAt: time.Date(2024, 4, 1, 14, 30, 45, 0, time.UTC),
+		}
+	}
+	return items
+}
+
+func newTestClient(endpoint string) *Client {
+	return NewClient(endpoint, WithToken("test-token"))
+}
+
+func TestSyncToServerReportsEveryChunkError(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if got := r.Header.Get("Authorization"); got != "X-CLI test-token" {
+			t.Errorf("Expected Authorization header, got '%s'", got)
+		}
+
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		// Reject every chunk but the first one to arrive
+		if n == 1 {
+			w.Write([]byte(`{"data":{"createClippings":[{"id":1}]}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":[{"message":"invalid payload","extensions":{"code":"BAD_USER_INPUT"}}]}`))
+	}))
+	defer server.Close()
+
+	report, err := newTestClient(server.URL).SyncToServer(context.Background(), testClippings(ChunkSize*3))
+	if err == nil {
+		t.Fatal("Expected an aggregated error")
+	}
+	if strings.Count(err.Error(), "failed") < 2 {
+		t.Errorf("Expected every chunk error in '%v'", err)
+	}
+
+	if report.Total != ChunkSize*3 || report.Succeeded != ChunkSize || report.Failed != ChunkSize*2 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	if len(report.Chunks) != 3 {
+		t.Fatalf("Expected 3 chunk reports, got %d", len(report.Chunks))
+	}
+
+	failed := 0
+	for i, chunk := range report.Chunks {
+		if chunk.Index != i+1 {
+			t.Errorf("Expected chunk index %d, got %d", i+1, chunk.Index)
+		}
+		if len(chunk.ClippingIndexes) != ChunkSize || chunk.ClippingIndexes[0] != i*ChunkSize {
+			t.Errorf("Unexpected clipping indexes for chunk %d: %v", chunk.Index, chunk.ClippingIndexes)
+		}
+		if chunk.Status != ChunkStatusFailed {
+			continue
+		}
+		failed++
+		if chunk.HTTPStatus != http.StatusBadRequest {
+			t.Errorf("Expected HTTP 400, got %d", chunk.HTTPStatus)
+		}
+		if len(chunk.GraphQLErrors) != 1 || chunk.GraphQLErrors[0].Extensions["code"] != "BAD_USER_INPUT" {
+			t.Errorf("Expected GraphQL error extensions, got %+v", chunk.GraphQLErrors)
+		}
+	}
+	if failed != 2 {
+		t.Errorf("Expected 2 failed chunks, got %d", failed)
+	}
+}
+
+func TestSyncToServerSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"createClippings":[{"id":1}]}}`))
+	}))
+	defer server.Close()
+
+	report, err := newTestClient(server.URL).SyncToServer(context.Background(), testClippings(ChunkSize+1))
+	if err != nil {
+		t.Fatalf("SyncToServer failed: %v", err)
+	}
+	if report.Succeeded != ChunkSize+1 || report.Failed != 0 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	for _, chunk := range report.Chunks {
+		if chunk.Status != ChunkStatusOK || chunk.HTTPStatus != http.StatusOK {
+			t.Errorf("Unexpected chunk report: %+v", chunk)
+		}
+	}
+}
+
+func TestSyncToServerRetriesValidRemainder(t *testing.T) {
+	var payloads [][]clippingInput
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables createClippingsVariables `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		mu.Lock()
+		payloads = append(payloads, req.Variables.Payload)
+		attempt := len(payloads)
+		mu.Unlock()
+
+		if attempt == 1 {
+			w.Write([]byte(`{"errors":[
+				{"message":"content too long","path":["createClippings",1],"extensions":{"code":"BAD_USER_INPUT"}},
+				{"message":"duplicate","extensions":{"index":3}}
+			]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"createClippings":[{"id":1}]}}`))
+	}))
+	defer server.Close()
+
+	report, err := newTestClient(server.URL).SyncToServer(context.Background(), testClippings(5))
+	if err == nil {
+		t.Fatal("Expected rejected clippings to surface as an error")
+	}
+
+	if len(payloads) != 2 || len(payloads[1]) != 3 {
+		t.Fatalf("Expected a retry with 3 clippings, got %d requests", len(payloads))
+	}
+
+	chunk := report.Chunks[0]
+	if chunk.Status != ChunkStatusPartial || !chunk.Retried {
+		t.Errorf("Expected a retried partial chunk, got %+v", chunk)
+	}
+	if report.Succeeded != 3 || report.Failed != 2 {
+		t.Errorf("Unexpected totals: succeeded %d, failed %d", report.Succeeded, report.Failed)
+	}
+
+	rejected := report.Rejected()
+	if len(rejected) != 2 {
+		t.Fatalf("Expected 2 rejected clippings, got %d", len(rejected))
+	}
+	if rejected[0].Index != 1 || rejected[0].Message != "content too long" || rejected[0].Extensions["code"] != "BAD_USER_INPUT" {
+		t.Errorf("Unexpected first rejection: %+v", rejected[0])
+	}
+	if rejected[1].Index != 3 || rejected[1].Clipping.Content != "xxxx" {
+		t.Errorf("Unexpected second rejection: %+v", rejected[1])
+	}
+}
+
+func TestSyncToServerUnattributedErrorFailsChunk(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"errors":[{"message":"internal error","path":["createClippings"]}]}`))
+	}))
+	defer server.Close()
+
+	report, err := newTestClient(server.URL).SyncToServer(context.Background(), testClippings(3))
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if requests != 1 {
+		t.Errorf("Expected no retry, got %d requests", requests)
+	}
+	if report.Chunks[0].Status != ChunkStatusFailed || len(report.Chunks[0].Rejected) != 0 {
+		t.Errorf("Unexpected chunk report: %+v", report.Chunks[0])
+	}
+}
+
+func TestSyncToServerSplitsChunksByVisibility(t *testing.T) {
+	var mu sync.Mutex
+	visibleByTitle := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables createClippingsVariables `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		mu.Lock()
+		for _, item := range req.Variables.Payload {
+			if visible, seen := visibleByTitle[item.Title]; seen && visible != req.Variables.Visible {
+				t.Errorf("Book %q sent with mixed visibility", item.Title)
+			}
+			visibleByTitle[item.Title] = req.Variables.Visible
+		}
+		mu.Unlock()
+		w.Write([]byte(`{"data":{"createClippings":[]}}`))
+	}))
+	defer server.Close()
+
+	clippings := testClippings(4)
+	clippings[1].Title = "Work Handbook"
+	clippings[3].Title = "Work Handbook"
+
+	client := NewClient(server.URL, WithVisibility(VisibilityFunc(func(title string) bool {
+		return !strings.HasPrefix(title, "Work ")
+	})))
+
+	report, err := client.SyncToServer(context.Background(), clippings)
+	if err != nil {
+		t.Fatalf("SyncToServer failed: %v", err)
+	}
+
+	if visibleByTitle["Work Handbook"] || !visibleByTitle["Test Book"] {
+		t.Errorf("Unexpected visibility: %v", visibleByTitle)
+	}
+	if len(report.Chunks) != 2 {
+		t.Fatalf("Expected a public and a private chunk, got %d", len(report.Chunks))
+	}
+	private := report.Chunks[1]
+	if private.Visible || len(private.ClippingIndexes) != 2 || private.ClippingIndexes[0] != 1 || private.ClippingIndexes[1] != 3 {
+		t.Errorf("Unexpected private chunk: %+v", private)
+	}
+}
@@ -0,0 +1,75 @@
+package ckk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ClippingUpdate holds the fields to change on a clipping; nil fields are left as they are
+type ClippingUpdate struct {
+	Content *string `json:"content,omitempty"`
+	PageAt  *string `json:"pageAt,omitempty"`
+}
+
+// updateClippingVariables represents variables for the updateClipping mutation
+type updateClippingVariables struct {
+	ID int64 `json:"id"`
+	ClippingUpdate
+}
+
+// deleteClippingVariables represents variables for the deleteClipping mutation
+type deleteClippingVariables struct {
+	ID int64 `json:"id"`
+}
+
+// updateClippingResponse represents the response from the updateClipping mutation
+type updateClippingResponse struct {
+	UpdateClipping *clippingResult `json:"updateClipping"`
+}
+
+// deleteClippingResponse represents the response from the deleteClipping mutation
+type deleteClippingResponse struct {
+	DeleteClipping *clippingResult `json:"deleteClipping"`
+}
+
+// UpdateClipping changes the content or location of a clipping on the server
+func (c *Client) UpdateClipping(ctx context.Context, id int64, update ClippingUpdate) error {
+	if update.Content == nil && update.PageAt == nil {
+		return fmt.Errorf("nothing to update for clipping %d", id)
+	}
+
+	_, err := Do[updateClippingResponse](ctx, c, updateClippingOperation, updateClippingVariables{ID: id, ClippingUpdate: update})
+	if err != nil {
+		return fmt.Errorf("failed to update clipping %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteClipping removes a clipping from the server
+func (c *Client) DeleteClipping(ctx context.Context, id int64) error {
+	_, err := Do[deleteClippingResponse](ctx, c, deleteClippingOperation, deleteClippingVariables{ID: id})
+	if err != nil {
+		return fmt.Errorf("failed to delete clipping %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteClippings removes several clippings, continuing past failures.
+// It returns the IDs actually deleted and every error encountered.
+func (c *Client) DeleteClippings(ctx context.Context, ids []int64) ([]int64, error) {
+	var deleted []int64
+	var errs []error
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if err := c.DeleteClipping(ctx, id); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted = append(deleted, id)
+	}
+	return deleted, errors.Join(errs...)
+}
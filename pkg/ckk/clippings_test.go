@@ -0,0 +1,69 @@
+package ckk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpdateClippingSendsOnlyChangedFields(t *testing.T) {
+	var variables map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			OperationName string                 `json:"operationName"`
+			Variables     map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.OperationName != "updateClipping" {
+			t.Errorf("Unexpected operation %q", req.OperationName)
+		}
+		variables = req.Variables
+		w.Write([]byte(`{"data":{"updateClipping":{"id":12}}}`))
+	}))
+	defer server.Close()
+
+	content := "fixed typo"
+	if err := newTestClient(server.URL).UpdateClipping(context.Background(), 12, ClippingUpdate{Content: &content}); err != nil {
+		t.Fatalf("UpdateClipping failed: %v", err)
+	}
+
+	if variables["id"] != float64(12) || variables["content"] != "fixed typo" {
+		t.Errorf("Unexpected variables: %v", variables)
+	}
+	if _, ok := variables["pageAt"]; ok {
+		t.Errorf("Expected pageAt to be omitted, got %v", variables)
+	}
+
+	if err := newTestClient(server.URL).UpdateClipping(context.Background(), 12, ClippingUpdate{}); err == nil {
+		t.Error("Expected an error for an empty update")
+	}
+}
+
+func TestDeleteClippingsContinuesPastFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables deleteClippingVariables `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.Variables.ID == 2 {
+			w.Write([]byte(`{"errors":[{"message":"not found"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"deleteClipping":{"id":1}}}`))
+	}))
+	defer server.Close()
+
+	deleted, err := newTestClient(server.URL).DeleteClippings(context.Background(), []int64{1, 2, 3})
+	if err == nil {
+		t.Fatal("Expected an error for the missing clipping")
+	}
+	if len(deleted) != 2 || deleted[0] != 1 || deleted[1] != 3 {
+		t.Errorf("Expected clippings 1 and 3 deleted, got %v", deleted)
+	}
+}
@@ -0,0 +1,2 @@
+// Package ckk is a client for the ClippingKK GraphQL API: syncing, fetching, editing and deleting clippings.
+package ckk
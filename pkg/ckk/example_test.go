@@ -0,0 +1,77 @@
+package ckk_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/clippingkk/cli/pkg/ckk"
+	"github.com/clippingkk/cli/pkg/clippings"
+)
+
+func ExampleClient_SyncToServer() {
+	// A stand-in for the ClippingKK API accepting every upload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"createClippings":[{"id":1}]}}`))
+	}))
+	defer server.Close()
+
+	client := ckk.NewClient(server.URL,
+		ckk.WithToken("my-token"),
+		ckk.WithVisibility(ckk.VisibilityFunc(func(title string) bool {
+			return title != "Diary"
+		})),
+	)
+
+	items := []clippings.Clipping{
+		{Title: "Dune", Content: "Fear is the mind-killer.", CreatedAt: time.Now()},
+		{Title: "Diary", Content: "Not for everyone.", CreatedAt: time.Now()},
+	}
+	report, err := client.SyncToServer(context.Background(), items)
+	if err != nil {
+		panic(err)
+	}
+	for _, chunk := range report.Chunks {
+		fmt.Printf("chunk %d: %d clippings, public: %v\n", chunk.Index, len(chunk.ClippingIndexes), chunk.Visible)
+	}
+	fmt.Printf("%d of %d uploaded\n", report.Succeeded, report.Total)
+	// Output:
+	// chunk 1: 1 clippings, public: true
+	// chunk 2: 1 clippings, public: false
+	// 2 of 2 uploaded
+}
+
+func ExampleDo() {
+	// A stand-in for the ClippingKK API answering a custom query
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"me":{"id":7,"name":"reader"}}}`))
+	}))
+	defer server.Close()
+
+	op, err := ckk.NewOperation(`query whoAmI { me { id name } }`)
+	if err != nil {
+		panic(err)
+	}
+	type result struct {
+		Me struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"me"`
+	}
+
+	client := ckk.NewClient(server.URL, ckk.WithToken("my-token"))
+	data, err := ckk.Do[result](context.Background(), client, op, nil)
+	if err != nil {
+		var respErr *ckk.ResponseError
+		if errors.As(err, &respErr) && respErr.HasCode("UNAUTHENTICATED") {
+			fmt.Println("log in again")
+		}
+		return
+	}
+	fmt.Println(data.Me.ID, data.Me.Name)
+	// Output:
+	// 7 reader
+}
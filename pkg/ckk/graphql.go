@@ -0,0 +1,235 @@
+package ckk
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// operationFiles holds the operations the client sends, one per file named
+// after the operation
+//
+//go:embed graphql/*.graphql
+var operationFiles embed.FS
+
+// Operations used by the client
+var (
+	createClippingsOperation = mustOperation("createClippings")
+	updateClippingOperation  = mustOperation("updateClipping")
+	deleteClippingOperation  = mustOperation("deleteClipping")
+	clippingsOperation       = mustOperation("clippings")
+	searchBooksOperation     = mustOperation("searchBooks")
+	meOperation              = mustOperation("me")
+)
+
+// operationPattern matches the definition of a named query or mutation
+var operationPattern = regexp.MustCompile(`^\s*(?:#[^\n]*\n\s*)*(query|mutation)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// Operation is a named GraphQL query or mutation
+type Operation struct {
+	// Name is the operation name, sent as operationName
+	Name string
+	// Type is "query" or "mutation"
+	Type string
+	// Document is the GraphQL source of the operation
+	Document string
+}
+
+// NewOperation reads the name and type of a document holding a single named
+// query or mutation, as written in a .graphql file
+func NewOperation(document string) (Operation, error) {
+	match := operationPattern.FindStringSubmatch(document)
+	if match == nil {
+		return Operation{}, fmt.Errorf("invalid GraphQL operation: expected a named query or mutation")
+	}
+	return Operation{Name: match[2], Type: match[1], Document: strings.TrimSpace(document)}, nil
+}
+
+// mustOperation loads graphql/<name>.graphql, panicking when it is missing
+// or defines another operation
+func mustOperation(name string) Operation {
+	data, err := operationFiles.ReadFile("graphql/" + name + ".graphql")
+	if err != nil {
+		panic(err)
+	}
+	op, err := NewOperation(string(data))
+	if err != nil {
+		panic(fmt.Sprintf("graphql/%s.graphql: %v", name, err))
+	}
+	if op.Name != name {
+		panic(fmt.Sprintf("graphql/%s.graphql defines operation %q", name, op.Name))
+	}
+	return op
+}
+
+// request returns the request running the operation with variables
+func (o Operation) request(variables interface{}) graphQLRequest {
+	return graphQLRequest{OperationName: o.Name, Query: o.Document, Variables: variables}
+}
+
+// graphQLRequest represents a GraphQL request
+type graphQLRequest struct {
+	OperationName string      `json:"operationName"`
+	Query         string      `json:"query"`
+	Variables     interface{} `json:"variables,omitempty"`
+}
+
+// graphQLResponse represents a GraphQL response whose data decodes into T
+type graphQLResponse[T any] struct {
+	Data   *T             `json:"data"`
+	Errors []GraphQLError `json:"errors"`
+}
+
+// GraphQLError represents a GraphQL error
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLLocation      `json:"locations"`
+	Path       []interface{}          `json:"path"`
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+// GraphQLLocation represents error location
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// Code returns the "code" extension of the error, such as "UNAUTHENTICATED",
+// or "" when the server gave none
+func (e GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// ResponseError is returned when the server answers with an HTTP error
+// status or with GraphQL errors
+type ResponseError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Errors are the GraphQL errors of the response, if any
+	Errors []GraphQLError
+	// Body is the response body of a non-200 response
+	Body string
+}
+
+func (e *ResponseError) Error() string {
+	if e.StatusCode != http.StatusOK {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("GraphQL error: %s", graphQLErrorMessages(e.Errors))
+}
+
+// HasCode reports whether any of the GraphQL errors carries the code extension
+func (e *ResponseError) HasCode(code string) bool {
+	for _, graphqlErr := range e.Errors {
+		if graphqlErr.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Do runs op with variables against the client endpoint and decodes the
+// response data into a T. Failures reported by the server are returned as a
+// *ResponseError.
+func Do[T any](ctx context.Context, c *Client, op Operation, variables interface{}) (T, error) {
+	var data T
+	_, err := c.post(ctx, op.request(variables), &data)
+	return data, err
+}
+
+// post sends a GraphQL request and decodes the response data into data when
+// it is non-nil. It returns the HTTP status alongside any failure so callers
+// can report it. A request rejected as unauthenticated is sent once more
+// after the Auth provider, if it is a Refresher, renewed its credentials.
+func (c *Client) post(ctx context.Context, request graphQLRequest, data interface{}) (int, error) {
+	if c.endpoint == "" {
+		return 0, fmt.Errorf("no valid endpoint configured")
+	}
+
+	reqBody, err := json.Marshal(request)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	status, err := c.send(ctx, reqBody, data)
+	refresher, ok := c.auth.(Refresher)
+	if !ok || !isUnauthenticated(err) {
+		return status, err
+	}
+	if refreshErr := refresher.Refresh(ctx); refreshErr != nil {
+		if errors.Is(refreshErr, errNothingToRefresh) {
+			return status, err
+		}
+		return status, fmt.Errorf("%w (refreshing the credentials failed: %v)", err, refreshErr)
+	}
+	return c.send(ctx, reqBody, data)
+}
+
+// send posts an encoded GraphQL request once
+func (c *Client) send(ctx context.Context, reqBody []byte, data interface{}) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		httpReq.Header.Set(key, value)
+	}
+	if c.auth != nil {
+		if err := c.auth.Apply(ctx, httpReq); err != nil {
+			return 0, err
+		}
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var graphqlResp graphQLResponse[json.RawMessage]
+
+	if resp.StatusCode != http.StatusOK {
+		// GraphQL servers often describe the failure in the body even on non-200 responses
+		respErr := &ResponseError{StatusCode: resp.StatusCode, Body: string(body)}
+		if json.Unmarshal(body, &graphqlResp) == nil {
+			respErr.Errors = graphqlResp.Errors
+		}
+		return resp.StatusCode, respErr
+	}
+
+	if err := json.Unmarshal(body, &graphqlResp); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(graphqlResp.Errors) > 0 {
+		return resp.StatusCode, &ResponseError{StatusCode: resp.StatusCode, Errors: graphqlResp.Errors}
+	}
+
+	if data != nil && graphqlResp.Data != nil {
+		if err := json.Unmarshal(*graphqlResp.Data, data); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,163 @@
+package ckk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRequest is a request received by the fake GraphQL server
+type fakeRequest struct {
+	OperationName string          `json:"operationName"`
+	Query         string          `json:"query"`
+	Variables     json.RawMessage `json:"variables"`
+	Header        http.Header     `json:"-"`
+}
+
+// fakeServer is a local GraphQL server answering each operation with a
+// canned response body and status
+type fakeServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]fakeResponse
+	requests  []fakeRequest
+}
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	f := &fakeServer{responses: make(map[string]fakeResponse)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fakeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Header = r.Header.Clone()
+
+		f.mu.Lock()
+		f.requests = append(f.requests, req)
+		resp, ok := f.responses[req.OperationName]
+		f.mu.Unlock()
+
+		if !ok {
+			resp = fakeResponse{http.StatusOK, `{"errors":[{"message":"unknown operation","extensions":{"code":"GRAPHQL_VALIDATION_FAILED"}}]}`}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// respond sets the answer to an operation
+func (f *fakeServer) respond(operation string, status int, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[operation] = fakeResponse{status, body}
+}
+
+// lastRequest returns the most recent request
+func (f *fakeServer) lastRequest(t *testing.T) fakeRequest {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests) == 0 {
+		t.Fatal("the fake server received no request")
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+func TestEmbeddedOperations(t *testing.T) {
+	for _, op := range []Operation{
+		createClippingsOperation, updateClippingOperation, deleteClippingOperation,
+		clippingsOperation, searchBooksOperation, meOperation,
+	} {
+		if !strings.HasPrefix(op.Document, op.Type+" "+op.Name) {
+			t.Errorf("Operation %q has an unexpected document:\n%s", op.Name, op.Document)
+		}
+	}
+	if clippingsOperation.Type != "query" || createClippingsOperation.Type != "mutation" {
+		t.Errorf("Unexpected operation types: %q, %q", clippingsOperation.Type, createClippingsOperation.Type)
+	}
+}
+
+func TestNewOperation(t *testing.T) {
+	op, err := NewOperation("# Books of the user\nquery myBooks($limit: Int) { books(limit: $limit) { id } }\n")
+	if err != nil {
+		t.Fatalf("NewOperation failed: %v", err)
+	}
+	if op.Name != "myBooks" || op.Type != "query" {
+		t.Errorf("Unexpected operation: %+v", op)
+	}
+
+	for _, document := range []string{"{ me { id } }", "subscription events { id }", ""} {
+		if _, err := NewOperation(document); err == nil {
+			t.Errorf("Expected an error for %q", document)
+		}
+	}
+}
+
+func TestDoDecodesTypedData(t *testing.T) {
+	server := newFakeServer(t)
+	server.respond("searchBooks", http.StatusOK, `{"data":{"searchBooks":[{"id":"42","title":"Bad Blood","author":"John Carreyrou"}]}}`)
+
+	client := NewClient(server.URL, WithToken("test-token"))
+	data, err := Do[searchBooksResponse](context.Background(), client, searchBooksOperation, searchBooksVariables{Query: "bad blood"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if len(data.SearchBooks) != 1 || data.SearchBooks[0].ID != "42" {
+		t.Errorf("Unexpected data: %+v", data)
+	}
+
+	req := server.lastRequest(t)
+	if req.OperationName != "searchBooks" || req.Query != searchBooksOperation.Document {
+		t.Errorf("Unexpected request: %+v", req)
+	}
+	if string(req.Variables) != `{"query":"bad blood"}` {
+		t.Errorf("Unexpected variables: %s", req.Variables)
+	}
+	if got := req.Header.Get("Authorization"); got != "X-CLI test-token" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestDoReturnsTypedErrors(t *testing.T) {
+	server := newFakeServer(t)
+	server.respond("me", http.StatusOK, `{"data":null,"errors":[{"message":"token expired","path":["me"],"extensions":{"code":"UNAUTHENTICATED","expiredAt":"2024-01-01"}}]}`)
+	server.respond("clippings", http.StatusServiceUnavailable, `maintenance`)
+
+	client := NewClient(server.URL)
+	_, err := Do[meResponse](context.Background(), client, meOperation, nil)
+
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("Expected a *ResponseError, got %T: %v", err, err)
+	}
+	if !respErr.HasCode("UNAUTHENTICATED") || respErr.Errors[0].Extensions["expiredAt"] != "2024-01-01" {
+		t.Errorf("Unexpected errors: %+v", respErr.Errors)
+	}
+	if err.Error() != "GraphQL error: token expired" {
+		t.Errorf("Unexpected message: %v", err)
+	}
+
+	_, err = Do[clippingsResponse](context.Background(), client, clippingsOperation, clippingsVariables{})
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusServiceUnavailable || respErr.Body != "maintenance" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if respErr.HasCode("UNAUTHENTICATED") {
+		t.Error("Expected no error code for a plain HTTP failure")
+	}
+}
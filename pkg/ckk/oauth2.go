@@ -0,0 +1,280 @@
+package ckk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// expiryDelta renews OAuth2 access tokens this long before they expire, so
+// a token does not run out while a request is in flight
+const expiryDelta = 30 * time.Second
+
+// pollUnit is the unit of the device flow polling interval, shortened in tests
+var pollUnit = time.Second
+
+// OAuth2Config describes an OAuth2 authorization server offering the device
+// authorization grant (RFC 8628)
+type OAuth2Config struct {
+	ClientID string
+	// DeviceAuthURL is the device authorization endpoint
+	DeviceAuthURL string
+	// TokenURL is the token endpoint, also used to refresh tokens
+	TokenURL string
+	Scopes   []string
+	// HTTPClient talks to the authorization server, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+// OAuth2Token is an access token with the refresh token renewing it
+type OAuth2Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// Expiry is zero when the server did not say when the token expires
+	Expiry time.Time `json:"expiry,omitzero"`
+}
+
+// Expired reports whether the token expires within expiryDelta of now
+func (t OAuth2Token) Expired(now time.Time) bool {
+	return !t.Expiry.IsZero() && !now.Add(expiryDelta).Before(t.Expiry)
+}
+
+// DeviceCode is the answer of the device authorization endpoint: the user
+// opens VerificationURI and enters UserCode while the client polls
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	// ExpiresIn and Interval are in seconds
+	ExpiresIn int `json:"expires_in"`
+	Interval  int `json:"interval,omitempty"`
+}
+
+// OAuth2Error is an error response of the authorization server
+type OAuth2Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuth2Error) Error() string {
+	if e.Description == "" {
+		return "oauth2: " + e.Code
+	}
+	return fmt.Sprintf("oauth2: %s: %s", e.Code, e.Description)
+}
+
+// AuthorizeDevice starts the device authorization flow
+func (c OAuth2Config) AuthorizeDevice(ctx context.Context) (*DeviceCode, error) {
+	form := url.Values{"client_id": {c.ClientID}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	var code DeviceCode
+	if err := c.postForm(ctx, c.DeviceAuthURL, form, &code); err != nil {
+		return nil, fmt.Errorf("failed to start device authorization: %w", err)
+	}
+	if code.DeviceCode == "" || code.UserCode == "" || code.VerificationURI == "" {
+		return nil, fmt.Errorf("failed to start device authorization: incomplete response")
+	}
+	return &code, nil
+}
+
+// PollToken waits until the user approved the device code and returns the
+// token, or fails when the user denied it or the code expired
+func (c OAuth2Config) PollToken(ctx context.Context, code *DeviceCode) (*OAuth2Token, error) {
+	interval := time.Duration(code.Interval) * pollUnit
+	if interval <= 0 {
+		interval = 5 * pollUnit
+	}
+	if code.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(code.ExpiresIn)*pollUnit)
+		defer cancel()
+	}
+
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {code.DeviceCode},
+		"client_id":   {c.ClientID},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("the device code expired before it was approved")
+			}
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		token, err := c.requestToken(ctx, form)
+		var oauthErr *OAuth2Error
+		if !errors.As(err, &oauthErr) {
+			return token, err
+		}
+		switch oauthErr.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * pollUnit
+		case "access_denied":
+			return nil, fmt.Errorf("the authorization was denied")
+		case "expired_token":
+			return nil, fmt.Errorf("the device code expired before it was approved")
+		default:
+			return nil, err
+		}
+	}
+}
+
+// Refresh exchanges a refresh token for a new token. The refresh token is
+// kept when the server does not issue a new one.
+func (c OAuth2Config) Refresh(ctx context.Context, refreshToken string) (*OAuth2Token, error) {
+	token, err := c.requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {c.ClientID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+// requestToken posts a grant to the token endpoint
+func (c OAuth2Config) requestToken(ctx context.Context, form url.Values) (*OAuth2Token, error) {
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := c.postForm(ctx, c.TokenURL, form, &resp); err != nil {
+		return nil, err
+	}
+	if resp.AccessToken == "" {
+		return nil, fmt.Errorf("the token response holds no access token")
+	}
+
+	token := &OAuth2Token{AccessToken: resp.AccessToken, RefreshToken: resp.RefreshToken}
+	if resp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// postForm posts form to endpoint and decodes the JSON answer into v,
+// turning error responses into an *OAuth2Error
+func (c OAuth2Config) postForm(ctx context.Context, endpoint string, form url.Values, v interface{}) error {
+	if endpoint == "" {
+		return fmt.Errorf("no OAuth2 endpoint configured")
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &OAuth2Error{}
+		if json.Unmarshal(body, oauthErr) == nil && oauthErr.Code != "" {
+			return oauthErr
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// OAuth2Auth sends an OAuth2 access token as bearer token, refreshing it
+// when it expires or the server rejects it
+type OAuth2Auth struct {
+	config    OAuth2Config
+	onRefresh func(OAuth2Token) error
+
+	mu    sync.Mutex
+	token OAuth2Token
+}
+
+// NewOAuth2Auth authenticates with token. onRefresh, when non-nil, is
+// called with every renewed token so it can be saved.
+func NewOAuth2Auth(config OAuth2Config, token OAuth2Token, onRefresh func(OAuth2Token) error) *OAuth2Auth {
+	return &OAuth2Auth{config: config, token: token, onRefresh: onRefresh}
+}
+
+// Token returns the current token
+func (a *OAuth2Auth) Token() OAuth2Token {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.token
+}
+
+// Apply implements Auth
+func (a *OAuth2Auth) Apply(ctx context.Context, req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token.Expired(time.Now()) {
+		if err := a.refresh(ctx); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token.AccessToken)
+	return nil
+}
+
+// Refresh implements Refresher
+func (a *OAuth2Auth) Refresh(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.refresh(ctx)
+}
+
+// refresh renews the token; the caller holds a.mu
+func (a *OAuth2Auth) refresh(ctx context.Context) error {
+	if a.token.RefreshToken == "" {
+		return ErrTokenExpired
+	}
+	token, err := a.config.Refresh(ctx, a.token.RefreshToken)
+	if err != nil {
+		var oauthErr *OAuth2Error
+		if errors.As(err, &oauthErr) && oauthErr.Code == "invalid_grant" {
+			return fmt.Errorf("%w: %v", ErrTokenExpired, err)
+		}
+		return err
+	}
+	a.token = *token
+	if a.onRefresh != nil {
+		if err := a.onRefresh(*token); err != nil {
+			return fmt.Errorf("failed to save refreshed token: %w", err)
+		}
+	}
+	return nil
+}
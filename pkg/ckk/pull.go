@@ -0,0 +1,135 @@
+package ckk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/clippingkk/cli/pkg/clippings"
+)
+
+// DefaultPageSize is the number of clippings fetched per query when paging
+const DefaultPageSize = 100
+
+// paginationInput represents limit/offset paging for list queries
+type paginationInput struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// clippingsVariables represents variables for the clippings query
+type clippingsVariables struct {
+	Pagination paginationInput `json:"pagination"`
+	Since      *string         `json:"since,omitempty"`
+}
+
+// clippingsResponse represents the response from the clippings query
+type clippingsResponse struct {
+	Clippings []remoteClipping `json:"clippings"`
+}
+
+// remoteClipping represents a clipping stored on the ClippingKK service
+type remoteClipping struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	PageAt    string `json:"pageAt"`
+	BookID    string `json:"bookID"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// toClipping converts a remote clipping to the local clipping model. It
+// fails when the server sends an unreadable creation date.
+func (r remoteClipping) toClipping() (clippings.Clipping, error) {
+	createdAt, err := time.Parse(time.RFC3339, r.CreatedAt)
+	if err != nil {
+		return clippings.Clipping{}, fmt.Errorf("failed to read the creation date of clipping %d: %w", r.ID, err)
+	}
+	return clippings.Clipping{
+		ID:        r.ID,
+		Title:     r.Title,
+		Content:   r.Content,
+		PageAt:    r.PageAt,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// FetchOptions filters the clippings returned by FetchClippings
+type FetchOptions struct {
+	// Since keeps clippings created at or after this time, when non-zero
+	Since time.Time
+	// Books keeps clippings of these titles only, compared case-insensitively
+	Books []string
+	// PageSize is the number of clippings per query, DefaultPageSize when zero
+	PageSize int
+	// OnSkip is told about every clipping left out because the server sent
+	// an unreadable creation date
+	OnSkip func(err error)
+}
+
+// FetchClippings pages through the user's clippings on the server. Paging
+// stops at a short page, or at a page bringing no clipping not seen yet,
+// in case the server ignores the offset.
+func (c *Client) FetchClippings(ctx context.Context, opts FetchOptions) ([]clippings.Clipping, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	variables := clippingsVariables{Pagination: paginationInput{Limit: pageSize}}
+	if !opts.Since.IsZero() {
+		since := opts.Since.UTC().Format(time.RFC3339)
+		variables.Since = &since
+	}
+
+	var items []clippings.Clipping
+	seen := make(map[int64]bool)
+	for {
+		data, err := Do[clippingsResponse](ctx, c, clippingsOperation, variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch clippings at offset %d: %w", variables.Pagination.Offset, err)
+		}
+
+		fresh := 0
+		for _, remote := range data.Clippings {
+			if remote.ID != 0 && seen[remote.ID] {
+				continue
+			}
+			seen[remote.ID] = true
+			fresh++
+
+			item, err := remote.toClipping()
+			if err != nil {
+				if opts.OnSkip != nil {
+					opts.OnSkip(err)
+				}
+				continue
+			}
+			if opts.matches(item) {
+				items = append(items, item)
+			}
+		}
+
+		if len(data.Clippings) < pageSize || fresh == 0 {
+			return items, nil
+		}
+		variables.Pagination.Offset += len(data.Clippings)
+	}
+}
+
+// matches applies the filters locally, in case the server ignores them
+func (o FetchOptions) matches(item clippings.Clipping) bool {
+	if !o.Since.IsZero() && item.CreatedAt.Before(o.Since) {
+		return false
+	}
+	if len(o.Books) == 0 {
+		return true
+	}
+	for _, book := range o.Books {
+		if strings.EqualFold(strings.TrimSpace(book), item.Title) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package ckk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchClippingsPagesAndFilters(t *testing.T) {
+	const total = 5
+	var offsets []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables clippingsVariables `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.Variables.Since == nil || *req.Variables.Since != "2024-01-01T00:00:00Z" {
+			t.Errorf("Expected since variable, got %v", req.Variables.Since)
+		}
+
+		page := req.Variables.Pagination
+		offsets = append(offsets, page.Offset)
+
+		var clippings []remoteClipping
+		for i := page.Offset; i < total && i < page.Offset+page.Limit; i++ {
+			title := "Book A"
+			if i%2 == 1 {
+				title = "Book B"
+			}
+			clippings = append(clippings, remoteClipping{
+				ID:        int64(i + 1),
+				Title:     title,
+				Content:   fmt.Sprintf("content %d", i),
+				CreatedAt: time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": clippingsResponse{Clippings: clippings},
+		})
+	}))
+	defer server.Close()
+
+	clippings, err := newTestClient(server.URL).FetchClippings(context.Background(), FetchOptions{
+		Since:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Books:    []string{"book a"},
+		PageSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("FetchClippings failed: %v", err)
+	}
+
+	if len(offsets) != 3 || offsets[2] != 4 {
+		t.Errorf("Expected 3 pages, got offsets %v", offsets)
+	}
+	if len(clippings) != 3 {
+		t.Fatalf("Expected 3 clippings of Book A, got %d", len(clippings))
+	}
+	if clippings[1].ID != 3 || clippings[1].Title != "Book A" || clippings[1].CreatedAt.Day() != 3 {
+		t.Errorf("Unexpected clipping: %+v", clippings[1])
+	}
+}
+
+func TestFetchClippingsSkipsBadDates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": clippingsResponse{Clippings: []remoteClipping{
+				{ID: 7, Title: "Book A", CreatedAt: "yesterday"},
+				{ID: 8, Title: "Book A", CreatedAt: "2024-01-01T00:00:00Z"},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	var skipped []error
+	clippings, err := newTestClient(server.URL).FetchClippings(context.Background(), FetchOptions{
+		OnSkip: func(err error) { skipped = append(skipped, err) },
+	})
+	if err != nil {
+		t.Fatalf("FetchClippings failed: %v", err)
+	}
+	if len(clippings) != 1 || clippings[0].ID != 8 {
+		t.Errorf("Expected the readable clipping only, got %+v", clippings)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), "clipping 7") {
+		t.Errorf("Expected the unreadable date to be reported, got %v", skipped)
+	}
+}
+
+func TestFetchClippingsStopsWhenOffsetIsIgnored(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Always the first page, whatever the offset
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": clippingsResponse{Clippings: []remoteClipping{
+				{ID: 1, Title: "Book A", CreatedAt: "2024-01-01T00:00:00Z"},
+				{ID: 2, Title: "Book A", CreatedAt: "2024-01-02T00:00:00Z"},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	clippings, err := newTestClient(server.URL).FetchClippings(context.Background(), FetchOptions{PageSize: 2})
+	if err != nil {
+		t.Fatalf("FetchClippings failed: %v", err)
+	}
+	if len(clippings) != 2 || requests != 2 {
+		t.Errorf("Expected 2 clippings in 2 requests, got %d in %d", len(clippings), requests)
+	}
+}
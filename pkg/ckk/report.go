@@ -0,0 +1,178 @@
+package ckk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/clippingkk/cli/pkg/clippings"
+)
+
+// Chunk statuses reported in ChunkReport.Status
+const (
+	ChunkStatusOK = "ok"
+	// ChunkStatusPartial means some clippings were rejected and the rest uploaded
+	ChunkStatusPartial = "partial"
+	ChunkStatusFailed  = "failed"
+)
+
+// SyncReport summarises the outcome of a SyncToServer run
+type SyncReport struct {
+	Endpoint  string        `json:"endpoint"`
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Chunks    []ChunkReport `json:"chunks"`
+	// Warnings describes problems that did not stop the upload, such as
+	// books that could not be resolved
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Rejected returns every clipping the server rejected individually, across all chunks
+func (r *SyncReport) Rejected() []RejectedClipping {
+	var rejected []RejectedClipping
+	for _, chunk := range r.Chunks {
+		rejected = append(rejected, chunk.Rejected...)
+	}
+	return rejected
+}
+
+// ChunkReport describes the result of uploading a single chunk
+type ChunkReport struct {
+	Index           int            `json:"index"`
+	Status          string         `json:"status"`
+	HTTPStatus      int            `json:"httpStatus,omitempty"`
+	Visible         bool           `json:"visible"`
+	ClippingIndexes []int          `json:"clippingIndexes"`
+	Error           string         `json:"error,omitempty"`
+	GraphQLErrors   []GraphQLError `json:"graphqlErrors,omitempty"`
+	// Rejected lists clippings the server refused individually
+	Rejected []RejectedClipping `json:"rejected,omitempty"`
+	// Retried is set when the valid remainder of the chunk was uploaded again
+	Retried bool `json:"retried,omitempty"`
+}
+
+// RejectedClipping is a clipping refused by the server, with the reason it gave
+type RejectedClipping struct {
+	// Index is the position of the clipping in the synced input
+	Index      int                    `json:"index"`
+	Clipping   clippings.Clipping     `json:"clipping"`
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Err aggregates every failed chunk and rejected clipping into a single error,
+// or returns nil when everything was uploaded
+func (r *SyncReport) Err() error {
+	var errs []error
+	for _, chunk := range r.Chunks {
+		if chunk.Status == ChunkStatusFailed {
+			errs = append(errs, fmt.Errorf("chunk %d failed: %s", chunk.Index, chunk.Error))
+		}
+		for _, rejected := range chunk.Rejected {
+			errs = append(errs, fmt.Errorf("clipping %d rejected: %s", rejected.Index, rejected.Message))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("upload failed with %d errors: %w", len(errs), errors.Join(errs...))
+}
+
+// ProgressReporter receives progress events while clippings are uploaded
+type ProgressReporter interface {
+	// SyncStarted is called once before any chunk is uploaded
+	SyncStarted(total, chunks int)
+	// ChunkFinished is called after each chunk, successful or not
+	ChunkFinished(chunk ChunkReport, chunks int)
+	// SyncFinished is called once all chunks are done
+	SyncFinished(report *SyncReport)
+}
+
+// NewWriterProgress returns a ProgressReporter printing human readable progress to w
+func NewWriterProgress(w io.Writer) ProgressReporter {
+	return &writerProgress{w: w}
+}
+
+type writerProgress struct {
+	w io.Writer
+}
+
+func (p *writerProgress) SyncStarted(total, chunks int) {
+	fmt.Fprintf(p.w, "Uploading %d clippings in %d chunks...\n", total, chunks)
+}
+
+func (p *writerProgress) ChunkFinished(chunk ChunkReport, chunks int) {
+	switch chunk.Status {
+	case ChunkStatusOK:
+		fmt.Fprintf(p.w, "✅ Chunk %d/%d completed: %d items\n", chunk.Index, chunks, len(chunk.ClippingIndexes))
+		return
+	case ChunkStatusPartial:
+		fmt.Fprintf(p.w, "⚠️  Chunk %d/%d completed: %d items, %d rejected\n",
+			chunk.Index, chunks, len(chunk.ClippingIndexes)-len(chunk.Rejected), len(chunk.Rejected))
+		return
+	}
+	fmt.Fprintf(p.w, "❌ Chunk %d/%d failed: %s\n", chunk.Index, chunks, chunk.Error)
+}
+
+func (p *writerProgress) SyncFinished(report *SyncReport) {
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(p.w, "⚠️  %s\n", warning)
+	}
+	if report.Failed == 0 {
+		fmt.Fprintf(p.w, "🎉 Successfully uploaded %d clippings!\n", report.Succeeded)
+		return
+	}
+	fmt.Fprintf(p.w, "⚠️  Uploaded %d of %d clippings, %d failed\n", report.Succeeded, report.Total, report.Failed)
+}
+
+// noopProgress discards all progress events
+type noopProgress struct{}
+
+func (noopProgress) SyncStarted(int, int)           {}
+func (noopProgress) ChunkFinished(ChunkReport, int) {}
+func (noopProgress) SyncFinished(*SyncReport)       {}
+
+// rejectedPayloadIndexes maps GraphQL errors onto the payload entries they
+// refer to. The index is taken from the first numeric element of the error
+// path, e.g. ["createClippings", 3], or from an "index" extension. It reports
+// false when any error cannot be attributed to a single clipping, in which
+// case the whole chunk has to be treated as failed.
+func rejectedPayloadIndexes(errs []GraphQLError, size int) (map[int]GraphQLError, bool) {
+	if len(errs) == 0 {
+		return nil, false
+	}
+
+	rejected := make(map[int]GraphQLError, len(errs))
+	for _, e := range errs {
+		index, ok := payloadIndex(e)
+		if !ok || index < 0 || index >= size {
+			return nil, false
+		}
+		rejected[index] = e
+	}
+	return rejected, true
+}
+
+// payloadIndex extracts the payload index a GraphQL error points at
+func payloadIndex(e GraphQLError) (int, bool) {
+	for _, element := range e.Path {
+		if n, ok := element.(float64); ok {
+			return int(n), true
+		}
+	}
+	if n, ok := e.Extensions["index"].(float64); ok {
+		return int(n), true
+	}
+	return 0, false
+}
+
+// graphQLErrorMessages joins the messages of a list of GraphQL errors
+func graphQLErrorMessages(errs []GraphQLError) string {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Message
+	}
+	return strings.Join(messages, "; ")
+}
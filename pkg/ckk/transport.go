@@ -0,0 +1,235 @@
+package ckk
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TLSOptions configures how the client verifies the server
+type TLSOptions struct {
+	// RootCAs verifies the server certificate instead of the system roots
+	// when non-nil, see LoadCABundle
+	RootCAs *x509.CertPool
+	// InsecureSkipVerify accepts any server certificate. Use it for local
+	// testing only; pinned keys are still checked against the server
+	// certificate itself.
+	InsecureSkipVerify bool
+	// PinnedKeys are "sha256/<base64>" hashes of public keys, as printed by
+	// PublicKeyPin. One of them must belong to the verified chain of the
+	// server certificate, or to the server certificate when verification is
+	// skipped; other certificates sent by the server do not count.
+	PinnedKeys []string
+}
+
+func (o TLSOptions) isZero() bool {
+	return o.RootCAs == nil && !o.InsecureSkipVerify && len(o.PinnedKeys) == 0
+}
+
+// ProxyOptions chooses the proxy requests go through
+type ProxyOptions struct {
+	// URL is an http://, https://, socks5:// or socks5h:// proxy, with
+	// optional credentials. When empty, $HTTPS_PROXY, $HTTP_PROXY and
+	// $NO_PROXY apply.
+	URL string
+	// NoProxy lists hosts reached directly: a host name also matching its
+	// subdomains, ".domain" for subdomains only, an IP address, a CIDR
+	// range or "*"
+	NoProxy []string
+}
+
+func (o ProxyOptions) isZero() bool {
+	return o.URL == "" && len(o.NoProxy) == 0
+}
+
+// WithTLS verifies the server with opts. Like client certificates, the
+// settings apply to a copy of the *http.Transport of the HTTP client.
+func WithTLS(opts TLSOptions) Option {
+	return func(c *Client) {
+		c.tls = opts
+	}
+}
+
+// WithProxy sends requests through the proxy of opts
+func WithProxy(opts ProxyOptions) Option {
+	return func(c *Client) {
+		c.proxy = opts
+	}
+}
+
+// LoadCABundle returns the system roots together with the certificates of
+// the PEM files
+func LoadCABundle(paths ...string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("failed to read CA bundle %s: no PEM certificate found", path)
+		}
+	}
+	return pool, nil
+}
+
+// PublicKeyPin returns the "sha256/<base64>" pin of the certificate's
+// public key, for TLSOptions.PinnedKeys
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ValidatePin checks the format of a pinned key
+func ValidatePin(pin string) error {
+	encoded, ok := strings.CutPrefix(pin, "sha256/")
+	if !ok {
+		return fmt.Errorf("invalid pinned key %q: must start with sha256/", pin)
+	}
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sum) != sha256.Size {
+		return fmt.Errorf("invalid pinned key %q: expected the base64 SHA-256 hash of a public key", pin)
+	}
+	return nil
+}
+
+// ValidateProxy checks that proxy is a URL the client can use
+func ValidateProxy(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("invalid proxy URL %q: must start with http://, https://, socks5:// or socks5h://", u.Redacted())
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: missing host", u.Redacted())
+	}
+	return nil
+}
+
+// withTransport returns a copy of httpClient whose transport is changed by
+// configure. A client with a custom RoundTripper is returned as it is since
+// its settings are out of reach.
+func withTransport(httpClient *http.Client, configure func(*http.Transport)) *http.Client {
+	var transport *http.Transport
+	switch t := httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return httpClient
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	configure(transport)
+
+	client := *httpClient
+	client.Transport = transport
+	return &client
+}
+
+// apply sets the TLS options on transport
+func (o TLSOptions) apply(transport *http.Transport) {
+	config := transport.TLSClientConfig
+	if o.RootCAs != nil {
+		config.RootCAs = o.RootCAs
+	}
+	if o.InsecureSkipVerify {
+		config.InsecureSkipVerify = true
+	}
+	if len(o.PinnedKeys) > 0 {
+		pins := o.PinnedKeys
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			for _, cert := range pinnableCertificates(state, config.InsecureSkipVerify) {
+				pin := PublicKeyPin(cert)
+				for _, pinned := range pins {
+					if pin == pinned {
+						return nil
+					}
+				}
+			}
+			return fmt.Errorf("the certificate of %s matches no pinned key", state.ServerName)
+		}
+	}
+}
+
+// pinnableCertificates returns the certificates a pinned key may match: the
+// verified chains, or only the server certificate when verification is
+// skipped since the rest of what the server sent proves nothing
+func pinnableCertificates(state tls.ConnectionState, insecure bool) []*x509.Certificate {
+	if insecure {
+		if len(state.PeerCertificates) == 0 {
+			return nil
+		}
+		return state.PeerCertificates[:1]
+	}
+	var certs []*x509.Certificate
+	for _, chain := range state.VerifiedChains {
+		certs = append(certs, chain...)
+	}
+	return certs
+}
+
+// apply sets the proxy of transport
+func (o ProxyOptions) apply(transport *http.Transport) {
+	proxy := transport.Proxy
+	if o.URL != "" {
+		u, err := url.Parse(o.URL)
+		if err == nil {
+			err = ValidateProxy(o.URL)
+		}
+		proxy = func(*http.Request) (*url.URL, error) {
+			return u, err
+		}
+	}
+	noProxy := o.NoProxy
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), noProxy) || proxy == nil {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+}
+
+// bypassProxy reports whether host matches an entry of noProxy
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case entry == "*":
+			return true
+		case ip != nil:
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+				return true
+			}
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return true
+			}
+		case strings.HasPrefix(entry, "."):
+			if strings.HasSuffix(host, entry) {
+				return true
+			}
+		case host == entry || strings.HasSuffix(host, "."+entry):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package ckk
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTLSServer is a local HTTPS GraphQL server answering the me query
+func newTLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"me":{"id":1,"name":"reader"}}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTLSOptions(t *testing.T) {
+	server := newTLSServer(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	pin := PublicKeyPin(server.Certificate())
+
+	other := testCertificate(t, "other")
+	tests := []struct {
+		name string
+		opts TLSOptions
+		ok   bool
+	}{
+		{"system roots", TLSOptions{}, false},
+		{"custom CA", TLSOptions{RootCAs: roots}, true},
+		{"insecure", TLSOptions{InsecureSkipVerify: true}, true},
+		{"pinned key", TLSOptions{RootCAs: roots, PinnedKeys: []string{PublicKeyPin(other.Leaf), pin}}, true},
+		{"pinned self-signed key", TLSOptions{InsecureSkipVerify: true, PinnedKeys: []string{pin}}, true},
+		{"other pinned key", TLSOptions{RootCAs: roots, PinnedKeys: []string{PublicKeyPin(other.Leaf)}}, false},
+	}
+	for _, test := range tests {
+		_, err := NewClient(server.URL, WithTLS(test.opts)).Me(context.Background())
+		if test.ok && err != nil {
+			t.Errorf("%s: Me failed: %v", test.name, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%s: expected the TLS handshake to fail", test.name)
+		}
+		if test.name == "other pinned key" && err != nil && !strings.Contains(err.Error(), "matches no pinned key") {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+
+	if err := ValidatePin(pin); err != nil {
+		t.Errorf("ValidatePin(%q) failed: %v", pin, err)
+	}
+	for _, invalid := range []string{"", "sha1/abc", "sha256/not-base64", "sha256/YWJj"} {
+		if ValidatePin(invalid) == nil {
+			t.Errorf("expected ValidatePin(%q) to fail", invalid)
+		}
+	}
+}
+
+func TestPinsIgnoreUnverifiedCertificates(t *testing.T) {
+	// The server sends its own certificate followed by one with the pinned
+	// key, which it does not hold the private key of
+	leaf := testCertificate(t, "leaf")
+	pinned := testCertificate(t, "pinned")
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"me":{"id":1,"name":"reader"}}}`))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.Certificate[0], pinned.Certificate[0]},
+		PrivateKey:  leaf.PrivateKey,
+	}}}
+	server.StartTLS()
+	defer server.Close()
+	endpoint := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf.Leaf)
+	tests := []struct {
+		name string
+		opts TLSOptions
+		ok   bool
+	}{
+		{"insecure, extra certificate pinned", TLSOptions{InsecureSkipVerify: true, PinnedKeys: []string{PublicKeyPin(pinned.Leaf)}}, false},
+		{"verified, extra certificate pinned", TLSOptions{RootCAs: roots, PinnedKeys: []string{PublicKeyPin(pinned.Leaf)}}, false},
+		{"insecure, server certificate pinned", TLSOptions{InsecureSkipVerify: true, PinnedKeys: []string{PublicKeyPin(leaf.Leaf)}}, true},
+		{"verified, server certificate pinned", TLSOptions{RootCAs: roots, PinnedKeys: []string{PublicKeyPin(leaf.Leaf)}}, true},
+	}
+	for _, test := range tests {
+		_, err := NewClient(endpoint, WithTLS(test.opts)).Me(context.Background())
+		if test.ok != (err == nil) {
+			t.Errorf("%s: unexpected result %v", test.name, err)
+		}
+	}
+}
+
+// counter counts events of a test server safely
+type counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *counter) add() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+}
+
+func (c *counter) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+// newHTTPProxy is a forwarding HTTP proxy counting the requests it relays
+func newHTTPProxy(t *testing.T) (*httptest.Server, *counter) {
+	t.Helper()
+	relayed := &counter{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.IsAbs() {
+			http.Error(w, "not a proxy request", http.StatusBadRequest)
+			return
+		}
+		relayed.add()
+
+		req, _ := http.NewRequest(r.Method, r.URL.String(), r.Body)
+		req.Header = r.Header.Clone()
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, relayed
+}
+
+func TestHTTPProxy(t *testing.T) {
+	server := newFakeServer(t)
+	server.respond("me", http.StatusOK, `{"data":{"me":{"id":1,"name":"reader"}}}`)
+	proxy, relayed := newHTTPProxy(t)
+
+	client := NewClient(server.URL, WithToken("abc"), WithProxy(ProxyOptions{URL: proxy.URL}))
+	if _, err := client.Me(context.Background()); err != nil {
+		t.Fatalf("Me through the proxy failed: %v", err)
+	}
+	if relayed.count() != 1 {
+		t.Errorf("expected the proxy to relay the request, relayed %d", relayed.count())
+	}
+
+	// Hosts listed in NoProxy are reached directly
+	client = NewClient(server.URL, WithProxy(ProxyOptions{URL: proxy.URL, NoProxy: []string{"127.0.0.0/8"}}))
+	if _, err := client.Me(context.Background()); err != nil {
+		t.Fatalf("Me without proxy failed: %v", err)
+	}
+	if relayed.count() != 1 {
+		t.Errorf("expected a direct request, relayed %d", relayed.count())
+	}
+
+	if _, err := NewClient(server.URL, WithProxy(ProxyOptions{URL: "ftp://proxy.example"})).Me(context.Background()); err == nil {
+		t.Error("expected an invalid proxy URL to fail")
+	}
+}
+
+// newSOCKS5Proxy is a SOCKS5 proxy without authentication (RFC 1928)
+// counting the connections it relays
+func newSOCKS5Proxy(t *testing.T) (string, *counter) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	connections := &counter{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			connections.add()
+			go relaySOCKS5(conn)
+		}
+	}()
+	return "socks5://" + listener.Addr().String(), connections
+}
+
+func relaySOCKS5(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting: version, methods; answer "no authentication"
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 0})
+
+	// Request: version, CONNECT, reserved, address type, address, port
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil || request[1] != 1 {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		length := make([]byte, 1)
+		io.ReadFull(conn, length)
+		name := make([]byte, length[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+func TestSOCKS5Proxy(t *testing.T) {
+	server := newTLSServer(t)
+	proxy, connections := newSOCKS5Proxy(t)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	client := NewClient(server.URL, WithTLS(TLSOptions{RootCAs: roots}), WithProxy(ProxyOptions{URL: proxy}))
+	if _, err := client.Me(context.Background()); err != nil {
+		t.Fatalf("Me through the SOCKS5 proxy failed: %v", err)
+	}
+	if connections.count() != 1 {
+		t.Errorf("expected one proxied connection, got %d", connections.count())
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	noProxy := []string{"internal.example", ".corp.example", "10.0.0.0/8", "::1"}
+	for host, want := range map[string]bool{
+		"internal.example":     true,
+		"api.internal.example": true,
+		"corp.example":         false,
+		"api.corp.example":     true,
+		"10.1.2.3":             true,
+		"::1":                  true,
+		"192.168.1.1":          false,
+		"example.com":          false,
+	} {
+		if got := bypassProxy(host, noProxy); got != want {
+			t.Errorf("bypassProxy(%q) = %v, expected %v", host, got, want)
+		}
+	}
+	if !bypassProxy("example.com", []string{"*"}) {
+		t.Error("expected * to bypass every host")
+	}
+}
+
+func TestOAuth2UsesClientTransport(t *testing.T) {
+	auth := newFakeAuthServer(t, 0)
+	proxy, relayed := newHTTPProxy(t)
+	server := newFakeServer(t)
+	server.respond("me", http.StatusOK, `{"data":{"me":{"id":1,"name":"reader"}}}`)
+
+	expired := OAuth2Token{AccessToken: "access-1", RefreshToken: "refresh-1", Expiry: time.Now().Add(-time.Minute)}
+	provider := NewOAuth2Auth(auth.config(), expired, nil)
+	client := NewClient(server.URL, WithAuth(provider), WithProxy(ProxyOptions{URL: proxy.URL}))
+	if _, err := client.Me(context.Background()); err != nil {
+		t.Fatalf("Me failed: %v", err)
+	}
+	// The refresh and the query both went through the proxy
+	if relayed.count() != 2 {
+		t.Errorf("expected 2 proxied requests, got %d", relayed.count())
+	}
+}
+
+func TestOAuth2IgnoresPinnedKeys(t *testing.T) {
+	server := newTLSServer(t)
+	cert := testCertificate(t, "auth")
+	auth := httptest.NewUnstartedServer(newFakeAuthServer(t, 0).Config.Handler)
+	auth.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	auth.StartTLS()
+	t.Cleanup(auth.Close)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	roots.AddCert(cert.Leaf)
+	// The certificate of the authorization server is issued for localhost
+	authURL := strings.Replace(auth.URL, "127.0.0.1", "localhost", 1)
+	config := OAuth2Config{ClientID: "ck-cli", TokenURL: authURL + "/token"}
+
+	expired := OAuth2Token{AccessToken: "access-1", RefreshToken: "refresh-1", Expiry: time.Now().Add(-time.Minute)}
+	provider := NewOAuth2Auth(config, expired, nil)
+	client := NewClient(server.URL, WithAuth(provider), WithTLS(TLSOptions{RootCAs: roots, PinnedKeys: []string{PublicKeyPin(server.Certificate())}}))
+	// The refresh reaches the authorization server although its key is not pinned
+	if _, err := client.Me(context.Background()); err != nil {
+		t.Fatalf("Me failed: %v", err)
+	}
+	if client.OAuth2HTTPClient() == client.HTTPClient() {
+		t.Error("expected the OAuth2 client to differ from the pinned API client")
+	}
+}
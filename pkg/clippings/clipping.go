@@ -0,0 +1,57 @@
+package clippings
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Clipping kinds recorded by Kindle
+const (
+	KindHighlight = "highlight"
+	KindNote      = "note"
+	KindBookmark  = "bookmark"
+)
+
+// Clipping represents a single clipping from Kindle
+type Clipping struct {
+	// ID is the ClippingKK identifier, only set for clippings pulled from the service
+	ID     int64  `json:"id,omitempty"`
+	Title  string `json:"title"`
+	Author string `json:"author,omitempty"`
+	// Kind is KindHighlight, KindNote or KindBookmark; empty when unknown
+	Kind      string    `json:"kind,omitempty"`
+	Content   string    `json:"content"`
+	PageAt    string    `json:"pageAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON implements custom JSON marshaling to maintain RFC3339 format
+func (c Clipping) MarshalJSON() ([]byte, error) {
+	type Alias Clipping
+	return json.Marshal(&struct {
+		*Alias
+		CreatedAt string `json:"createdAt"`
+	}{
+		Alias:     (*Alias)(&c),
+		CreatedAt: c.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling to parse RFC3339 format
+func (c *Clipping) UnmarshalJSON(data []byte) error {
+	type Alias Clipping
+	aux := &struct {
+		*Alias
+		CreatedAt string `json:"createdAt"`
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	var err error
+	c.CreatedAt, err = time.Parse(time.RFC3339, aux.CreatedAt)
+	return err
+}
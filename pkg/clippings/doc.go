@@ -0,0 +1,2 @@
+// Package clippings holds the Kindle clipping model shared by the parser, the ClippingKK client and the ck-cli commands.
+package clippings
@@ -0,0 +1,28 @@
+package clippings_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/clippingkk/cli/pkg/clippings"
+)
+
+func ExampleClipping() {
+	item := clippings.Clipping{
+		Title:     "Dune",
+		Author:    "Frank Herbert",
+		Kind:      clippings.KindHighlight,
+		Content:   "Fear is the mind-killer.",
+		PageAt:    "#100-101",
+		CreatedAt: time.Date(2024, 4, 1, 14, 30, 45, 0, time.UTC),
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(data))
+	// Output:
+	// {"title":"Dune","author":"Frank Herbert","kind":"highlight","content":"Fear is the mind-killer.","pageAt":"#100-101","createdAt":"2024-04-01T14:30:45Z"}
+}
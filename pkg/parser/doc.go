@@ -0,0 +1,2 @@
+// Package parser turns the "My Clippings.txt" file of an English or Chinese Kindle into clippings.Clipping values.
+package parser
@@ -0,0 +1,44 @@
+package parser_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/clippingkk/cli/pkg/parser"
+)
+
+func ExampleParse() {
+	input := `Dune (Frank Herbert)
+- Your Highlight on page 7 | location 100-101 | Added on Monday, April 1, 2024 2:30:45 PM
+
+Fear is the mind-killer.
+==========`
+
+	items, err := parser.Parse(input)
+	if err != nil {
+		panic(err)
+	}
+	for _, item := range items {
+		fmt.Printf("%s by %s, %s: %s\n", item.Title, item.Author, item.PageAt, item.Content)
+	}
+	// Output:
+	// Dune by Frank Herbert, #7: Fear is the mind-killer.
+}
+
+func ExampleParseReader() {
+	input := `Dune (Frank Herbert)
+- Your Note on page 7 | location 101 | Added on Monday, April 1, 2024 2:30:45 PM
+
+Remember this one.
+==========`
+
+	tokyo := time.FixedZone("JST", 9*3600)
+	items, err := parser.ParseReader(strings.NewReader(input), parser.Options{Location: tokyo, Strict: true})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(items[0].Kind, items[0].CreatedAt.UTC().Format(time.RFC3339))
+	// Output:
+	// note 2024-04-01T05:30:45Z
+}
@@ -2,19 +2,23 @@ package parser
 
 import (
 	"fmt"
+	"io"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
-	"github.com/clippingkk/cli/internal/models"
+	"github.com/clippingkk/cli/pkg/clippings"
 )
 
-// Language represents the detected language of Kindle clippings
+// Language is the language of the Kindle the clippings come from
 type Language int
 
 const (
+	// LanguageAuto detects the language from the clippings
+	LanguageAuto Language = iota
 	// LanguageEnglish represents English clippings
-	LanguageEnglish Language = iota
+	LanguageEnglish
 	// LanguageChinese represents Chinese clippings
 	LanguageChinese
 )
@@ -27,6 +31,9 @@ var (
 	englishLocationPattern = regexp.MustCompile(`\d+(-?\d+)?`)
 	chineseLocationPattern = regexp.MustCompile(`#?\d+(-?\d+)?`)
 
+	// Series groups such as "(Series: Book 1)" or "(Expanse #3)"
+	seriesPattern = regexp.MustCompile(`(?i):|#\s*\d+$|\b(book|vol\.?|volume)\s*\d+$`)
+
 	// Chinese character detection pattern
 	chinesePattern = regexp.MustCompile(`[\x{4E00}-\x{9FFF}\x{3000}-\x{303F}]`)
 
@@ -35,48 +42,58 @@ var (
 	chineseDateFormat = "2006-1-2 3:4:5 PM"
 )
 
-// ParseOptions contains configuration for parsing
-type ParseOptions struct {
-	// RemoveBOM whether to remove UTF-8 BOM
-	RemoveBOM bool
-}
-
-// DefaultParseOptions returns default parsing options
-func DefaultParseOptions() ParseOptions {
-	return ParseOptions{
-		RemoveBOM: true,
-	}
+// Options configures parsing. The zero value removes UTF-8 byte order marks,
+// detects the language, reads dates as UTC and skips malformed clippings.
+type Options struct {
+	// KeepBOM keeps UTF-8 byte order marks in titles and content
+	KeepBOM bool
+	// Language forces the language instead of detecting it
+	Language Language
+	// Location is the time zone of the Kindle clock, UTC when nil
+	Location *time.Location
+	// Strict fails on the first malformed clipping or date instead of
+	// skipping the clipping or dating it at the Unix epoch
+	Strict bool
 }
 
 // Parse parses Kindle clippings text and returns structured data
-func Parse(input string, opts ...ParseOptions) ([]models.ClippingItem, error) {
-	options := DefaultParseOptions()
+func Parse(input string, opts ...Options) ([]clippings.Clipping, error) {
+	var options Options
 	if len(opts) > 0 {
 		options = opts[0]
 	}
+	if options.Location == nil {
+		options.Location = time.UTC
+	}
 
-	// Remove BOM if requested
-	if options.RemoveBOM {
+	// Remove BOM unless asked to keep it
+	if !options.KeepBOM {
 		input = bomPattern.ReplaceAllString(input, "")
 	}
 
 	// Trim and validate input
 	input = strings.TrimSpace(input)
 	if input == "" {
-		return []models.ClippingItem{}, nil
+		return []clippings.Clipping{}, nil
 	}
 
 	// Detect language
-	language := detectLanguage(input)
+	language := options.Language
+	if language == LanguageAuto {
+		language = detectLanguage(input)
+	}
 
 	// Split into clipping groups
 	groups := splitIntoGroups(input)
 
 	// Parse each group
-	var result []models.ClippingItem
-	for _, group := range groups {
-		item, err := parseGroup(group, language)
+	var result []clippings.Clipping
+	for i, group := range groups {
+		item, err := parseGroup(group, language, options)
 		if err != nil {
+			if options.Strict {
+				return nil, fmt.Errorf("clipping %d: %w", i+1, err)
+			}
 			// Skip invalid clippings but continue processing
 			continue
 		}
@@ -88,10 +105,22 @@ func Parse(input string, opts ...ParseOptions) ([]models.ClippingItem, error) {
 	return result, nil
 }
 
+// ParseReader reads Kindle clippings from r and parses them like Parse
+func ParseReader(r io.Reader, opts ...Options) ([]clippings.Clipping, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clippings: %w", err)
+	}
+	return Parse(string(data), opts...)
+}
+
 // detectLanguage detects the language of the clippings
 func detectLanguage(input string) Language {
-	if strings.Contains(input, "Your Highlight on") {
-		return LanguageEnglish
+	// Appended chunks may hold only notes or bookmarks, so look beyond highlights
+	for _, marker := range []string{"Your Highlight on", "Your Note on", "Your Bookmark on"} {
+		if strings.Contains(input, marker) {
+			return LanguageEnglish
+		}
 	}
 	return LanguageChinese
 }
@@ -124,20 +153,24 @@ func splitIntoGroups(input string) [][]string {
 }
 
 // parseGroup parses a single clipping group
-func parseGroup(group []string, language Language) (*models.ClippingItem, error) {
+func parseGroup(group []string, language Language, opts Options) (*clippings.Clipping, error) {
 	// Validate group structure (minimum 4 lines: title, info, empty, content)
 	if len(group) < 4 {
 		return nil, fmt.Errorf("invalid group structure: not enough lines")
 	}
 
 	// Remove BOM from title
-	title := parseTitle(bomPattern.ReplaceAllString(group[0], ""))
+	titleLine := group[0]
+	if !opts.KeepBOM {
+		titleLine = bomPattern.ReplaceAllString(titleLine, "")
+	}
+	title := parseTitle(titleLine)
 	if title == "" {
 		return nil, fmt.Errorf("empty title")
 	}
 
 	// Parse location and date from info line
-	location, createdAt, err := parseInfo(group[1], language)
+	location, createdAt, err := parseInfo(group[1], language, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse info: %w", err)
 	}
@@ -148,8 +181,10 @@ func parseGroup(group []string, language Language) (*models.ClippingItem, error)
 		return nil, fmt.Errorf("empty content")
 	}
 
-	return &models.ClippingItem{
+	return &clippings.Clipping{
 		Title:     title,
+		Author:    parseAuthor(titleLine),
+		Kind:      parseKind(group[1]),
 		Content:   content,
 		PageAt:    location,
 		CreatedAt: createdAt,
@@ -175,8 +210,69 @@ func parseTitle(line string) string {
 	return strings.TrimSpace(title)
 }
 
+// parseAuthor extracts the author from the parenthesised groups of the title
+// line. Kindle puts the author last, but some books add a series group such
+// as "(Series: Book 1)" after it, so the last group that does not look like a
+// series wins, falling back to the first group.
+func parseAuthor(line string) string {
+	groups := parenthesisedGroups(strings.TrimSpace(line))
+	for i := len(groups) - 1; i >= 0; i-- {
+		if !seriesPattern.MatchString(groups[i]) {
+			return groups[i]
+		}
+	}
+	if len(groups) > 0 {
+		return groups[0]
+	}
+	return ""
+}
+
+// parenthesisedGroups returns the contents of the top-level parenthesised
+// groups of line, allowing nested pairs. A group opening the line is the
+// title itself and is skipped.
+func parenthesisedGroups(line string) []string {
+	var groups []string
+	depth, open := 0, 0
+	for i, r := range line {
+		switch r {
+		case '(', '（':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case ')', '）':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 && open > 0 {
+				_, size := utf8.DecodeRuneInString(line[open:])
+				start := open + size
+				if group := strings.TrimSpace(line[start:i]); group != "" {
+					groups = append(groups, group)
+				}
+			}
+		}
+	}
+	return groups
+}
+
+// parseKind tells highlights, notes and bookmarks apart from the info line
+func parseKind(line string) string {
+	switch {
+	case strings.Contains(line, "Your Highlight"), strings.Contains(line, "标注"):
+		return clippings.KindHighlight
+	case strings.Contains(line, "Your Note"), strings.Contains(line, "笔记"):
+		return clippings.KindNote
+	case strings.Contains(line, "Your Bookmark"), strings.Contains(line, "书签"):
+		return clippings.KindBookmark
+	default:
+		return ""
+	}
+}
+
 // parseInfo parses the info line to extract location and date
-func parseInfo(line string, language Language) (string, time.Time, error) {
+func parseInfo(line string, language Language, opts Options) (string, time.Time, error) {
 	// Split by pipe character
 	parts := strings.Split(line, "|")
 	if len(parts) < 2 {
@@ -216,12 +312,15 @@ func parseInfo(line string, language Language) (string, time.Time, error) {
 
 	switch language {
 	case LanguageEnglish:
-		createdAt, err = parseEnglishDate(dateSection)
+		createdAt, err = parseEnglishDate(dateSection, opts.Location)
 	case LanguageChinese:
-		createdAt, err = parseChineseDate(dateSection)
+		createdAt, err = parseChineseDate(dateSection, opts.Location)
 	}
 
 	if err != nil {
+		if opts.Strict {
+			return "", time.Time{}, fmt.Errorf("invalid date %q", dateSection)
+		}
 		// Return default time if parsing fails
 		createdAt = time.Unix(0, 0).UTC()
 	}
@@ -230,13 +329,13 @@ func parseInfo(line string, language Language) (string, time.Time, error) {
 }
 
 // parseEnglishDate parses English date format
-func parseEnglishDate(dateStr string) (time.Time, error) {
+func parseEnglishDate(dateStr string, loc *time.Location) (time.Time, error) {
 	dateStr = strings.TrimSpace(dateStr)
-	return time.Parse(englishDateFormat, dateStr)
+	return time.ParseInLocation(englishDateFormat, dateStr, loc)
 }
 
 // parseChineseDate parses Chinese date format
-func parseChineseDate(dateStr string) (time.Time, error) {
+func parseChineseDate(dateStr string, loc *time.Location) (time.Time, error) {
 	// Determine AM/PM
 	var ampm string
 	if strings.Contains(dateStr, "上午") {
@@ -256,5 +355,5 @@ func parseChineseDate(dateStr string) (time.Time, error) {
 	// Add AM/PM suffix
 	dateStr = dateStr + " " + ampm
 
-	return time.Parse(chineseDateFormat, dateStr)
+	return time.ParseInLocation(chineseDateFormat, dateStr, loc)
 }
@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/clippingkk/cli/pkg/clippings"
 )
 
 func TestParseEnglishClippings(t *testing.T) {
@@ -96,6 +98,10 @@ Some content here.
 	if clippings[0].Title != expected {
 		t.Errorf("Expected title '%s', got '%s'", expected, clippings[0].Title)
 	}
+	// The series group after the author is not the author
+	if clippings[0].Author != "Author Name" {
+		t.Errorf("Expected author 'Author Name', got '%s'", clippings[0].Author)
+	}
 }
 
 func TestParseBOMRemoval(t *testing.T) {
@@ -150,6 +156,7 @@ func TestDetectLanguage(t *testing.T) {
 		expected Language
 	}{
 		{"Your Highlight on page", LanguageEnglish},
+		{"- Your Note on page 3 | Location 40", LanguageEnglish},
 		{"您在位置", LanguageChinese},
 		{"Some other text", LanguageChinese}, // Default to Chinese
 	}
@@ -181,3 +188,91 @@ func TestParseTitle(t *testing.T) {
 		}
 	}
 }
+
+func TestParseAuthor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Simple Title", ""},
+		{"Title (Author)", "Author"},
+		{"Title (Series) (Carreyrou, John)", "Carreyrou, John"},
+		{"Title (Author (Editor))", "Author (Editor)"},
+		{"Some Book (Author Name) (Series: Book 1)", "Author Name"},
+		{"Leviathan Wakes (James S. A. Corey) (Expanse #1)", "James S. A. Corey"},
+		{"Title (Series: Book 1)", "Series: Book 1"},
+		{"深度工作（卡尔·纽波特）", "卡尔·纽波特"},
+		{"(Only Parentheses)", ""},
+		{"Title) with trailing paren", ""},
+	}
+
+	for _, test := range tests {
+		result := parseAuthor(test.input)
+		if result != test.expected {
+			t.Errorf("parseAuthor('%s') = '%s', expected '%s'", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"- Your Highlight on page 10 | Location 140-141 | Added on Monday, January 2, 2023 3:04:05 PM", clippings.KindHighlight},
+		{"- Your Note on page 10 | Location 141 | Added on Monday, January 2, 2023 3:04:05 PM", clippings.KindNote},
+		{"- Your Bookmark on page 12 | Location 160 | Added on Monday, January 2, 2023 3:04:05 PM", clippings.KindBookmark},
+		{"- 您在位置 #140-141的标注 | 添加于 2023年1月2日星期一 下午3:04:05", clippings.KindHighlight},
+		{"- 您在位置 #141的笔记 | 添加于 2023年1月2日星期一 下午3:04:05", clippings.KindNote},
+		{"- 您在位置 #160的书签 | 添加于 2023年1月2日星期一 下午3:04:05", clippings.KindBookmark},
+		{"- Something else", ""},
+	}
+
+	for _, test := range tests {
+		if result := parseKind(test.input); result != test.expected {
+			t.Errorf("parseKind('%s') = '%s', expected '%s'", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	input := `Dune (Frank Herbert)
+- Your Highlight on page 7 | location 100-101 | Added on Monday, April 1, 2024 2:30:45 PM
+
+Fear is the mind-killer.
+==========
+Broken Book (Someone)
+- Your Highlight on page 9 | location 120 | Added on sometime last week
+
+A highlight with a date Kindle never writes.
+==========`
+
+	items, err := Parse(input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(items) != 2 || !items[1].CreatedAt.Equal(time.Unix(0, 0)) {
+		t.Fatalf("expected the invalid date at the epoch, got %+v", items)
+	}
+
+	if _, err := Parse(input, Options{Strict: true}); err == nil || !strings.Contains(err.Error(), "clipping 2") {
+		t.Errorf("expected a strict error for clipping 2, got %v", err)
+	}
+
+	berlin := time.FixedZone("CET", 3600)
+	items, err = ParseReader(strings.NewReader(input), Options{Location: berlin})
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	if want := time.Date(2024, 4, 1, 13, 30, 45, 0, time.UTC); !items[0].CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, expected %v", items[0].CreatedAt, want)
+	}
+
+	items, err = Parse("\ufeff"+input, Options{KeepBOM: true, Language: LanguageChinese})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(items) == 0 || !strings.HasPrefix(items[0].Title, "\ufeff") {
+		t.Errorf("expected the BOM to be kept, got %+v", items)
+	}
+}